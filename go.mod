@@ -1,10 +1,17 @@
 module github.com/jslang/mailosaur-go
 
-go 1.13
+go 1.23.0
 
 require (
+	github.com/andybalholm/cascadia v1.2.0
 	github.com/brianvoe/gofakeit v3.18.0+incompatible
-	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/stretchr/testify v1.4.0
-	gopkg.in/yaml.v2 v2.2.4 // indirect
+	golang.org/x/net v0.38.0
+	gopkg.in/yaml.v2 v2.2.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
@@ -0,0 +1,186 @@
+package mailosaur
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is how serious an accessibility finding is.
+type Severity string
+
+// Severities of accessibility findings. Errors make a message unusable for some readers, warnings make it harder to use.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rules checked by CheckAccessibility.
+const (
+	RuleImageAlt        = "image-alt"
+	RuleDocumentLang    = "document-lang"
+	RuleLayoutTable     = "layout-table"
+	RuleLinkName        = "link-name"
+	RuleLinkText        = "link-text"
+	RuleHeadingOrder    = "heading-order"
+	RuleTextAlternative = "text-alternative"
+)
+
+// AccessibilityFinding is a single accessibility problem in a message.
+type AccessibilityFinding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	// Element is the opening tag of the offending element, empty for problems with the message as a whole.
+	Element string
+}
+
+func (f *AccessibilityFinding) String() string {
+	if f.Element == "" {
+		return fmt.Sprintf("%s %s: %s", f.Severity, f.Rule, f.Message)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", f.Severity, f.Rule, f.Message, f.Element)
+}
+
+// AccessibilityReport lists the accessibility problems found in a message.
+type AccessibilityReport struct {
+	Findings []*AccessibilityFinding
+}
+
+// AccessibilityError lists the error severity findings of an AccessibilityReport.
+type AccessibilityError struct {
+	Findings []*AccessibilityFinding
+}
+
+func (e *AccessibilityError) Error() string {
+	findings := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		findings = append(findings, finding.String())
+	}
+	return fmt.Sprintf("mailosaur: %d accessibility error(s): %s", len(e.Findings), strings.Join(findings, "; "))
+}
+
+// Err returns an *AccessibilityError listing every error severity finding, or nil if there are none. Warnings are not
+// errors.
+func (r *AccessibilityReport) Err() error {
+	var errs []*AccessibilityFinding
+	for _, finding := range r.Findings {
+		if finding.Severity == SeverityError {
+			errs = append(errs, finding)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &AccessibilityError{Findings: errs}
+}
+
+func (r *AccessibilityReport) add(rule string, severity Severity, element *Element, format string, args ...interface{}) {
+	finding := &AccessibilityFinding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if element != nil {
+		finding.Element = openingTag(element)
+	}
+	r.Findings = append(r.Findings, finding)
+}
+
+// lowInformationLinkText is link text that says nothing about where the link goes when read out of context.
+var lowInformationLinkText = map[string]bool{
+	"click here": true, "click": true, "here": true, "link": true, "this": true, "this link": true,
+	"more": true, "read more": true, "learn more": true, "go": true,
+}
+
+// CheckAccessibility checks the HTML body of a message for common email accessibility problems: images without alt
+// text, a missing document language, layout tables not marked as presentational, links without an accessible name or
+// with low information text such as "click here", skipped heading levels and a missing plain text alternative.
+// Returns ErrNoHTML if the message has no HTML body.
+func CheckAccessibility(msg *Message) (*AccessibilityReport, error) {
+	doc, err := msg.Document()
+	if err != nil {
+		return nil, err
+	}
+	report := &AccessibilityReport{}
+	find := func(selector string) []*Element {
+		elements, _ := doc.Find(selector) // selectors are constant and known to be valid
+		return elements
+	}
+
+	if root := find("html"); len(root) == 0 || strings.TrimSpace(root[0].Attributes["lang"]) == "" {
+		report.add(RuleDocumentLang, SeverityError, nil, "html element has no lang attribute")
+	}
+
+	for _, image := range find("img") {
+		if _, ok := image.Attributes["alt"]; !ok {
+			report.add(RuleImageAlt, SeverityError, image, `image has no alt attribute, use alt="" for decorative images`)
+		}
+	}
+
+	for _, table := range find("table") {
+		role := strings.ToLower(table.Attributes["role"])
+		if role == "presentation" || role == "none" {
+			continue
+		}
+		if headers, _ := table.Find("th, caption"); len(headers) > 0 {
+			continue
+		}
+		report.add(RuleLayoutTable, SeverityWarning, table, `layout table has no role="presentation"`)
+	}
+
+	for _, link := range find("a[href]") {
+		name := accessibleName(link)
+		if name == "" {
+			report.add(RuleLinkName, SeverityError, link, "link has no text, aria-label or image alt text")
+		} else if lowInformationLinkText[strings.ToLower(strings.TrimRight(name, ".!:>» "))] {
+			report.add(RuleLinkText, SeverityWarning, link, "link text %q does not describe its destination", name)
+		}
+	}
+
+	previous := 0
+	for _, heading := range find("h1, h2, h3, h4, h5, h6") {
+		level, _ := strconv.Atoi(heading.Tag[1:])
+		if previous > 0 && level > previous+1 {
+			report.add(RuleHeadingOrder, SeverityWarning, heading, "heading level skips from h%d to h%d", previous, level)
+		}
+		previous = level
+	}
+
+	if msg.Text == nil || strings.TrimSpace(msg.Text.Body) == "" {
+		report.add(RuleTextAlternative, SeverityWarning, nil, "message has no plain text alternative")
+	}
+	return report, nil
+}
+
+// accessibleName returns the name a screen reader announces for a link.
+func accessibleName(link *Element) string {
+	if label := strings.TrimSpace(link.Attributes["aria-label"]); label != "" {
+		return label
+	}
+	if link.Text != "" {
+		return link.Text
+	}
+	images, _ := link.Find("img[alt]")
+	for _, image := range images {
+		if alt := strings.TrimSpace(image.Attributes["alt"]); alt != "" {
+			return alt
+		}
+	}
+	return ""
+}
+
+// openingTag renders the opening tag of an element, with its attributes sorted by name.
+func openingTag(e *Element) string {
+	names := make([]string, 0, len(e.Attributes))
+	for name := range e.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("<" + e.Tag)
+	for _, name := range names {
+		fmt.Fprintf(&b, ` %s="%s"`, name, html.EscapeString(e.Attributes[name]))
+	}
+	b.WriteString(">")
+	return b.String()
+}
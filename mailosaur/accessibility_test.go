@@ -0,0 +1,86 @@
+package mailosaur_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAccessibility(t *testing.T) {
+	check := func(t *testing.T, html string, text string) *mailosaur.AccessibilityReport {
+		t.Parallel()
+		msg := &mailosaur.Message{HTML: &mailosaur.MessageContent{Body: html}}
+		if text != "" {
+			msg.Text = &mailosaur.MessageContent{Body: text}
+		}
+		report, err := mailosaur.CheckAccessibility(msg)
+		require.NoError(t, err)
+		return report
+	}
+
+	t.Run("accessible message", func(t *testing.T) {
+		report := check(t, `<html lang="en"><body>
+			<table role="presentation"><tr><td>
+				<img src="logo.png" alt="Acme"><img src="spacer.gif" alt="">
+				<h1>Welcome</h1><h2>Next steps</h2><h3>Verify</h3><h2>Help</h2>
+				<a href="https://example.com/verify">Verify your email</a>
+				<a href="https://example.com"><img src="home.png" alt="Acme home page"></a>
+			</td></tr></table>
+			<table><tr><th>Item</th><th>Price</th></tr></table>
+		</body></html>`, "Welcome")
+		require.Empty(t, report.Findings)
+		require.NoError(t, report.Err())
+	})
+
+	t.Run("reports every finding", func(t *testing.T) {
+		report := check(t, `<html><body>
+			<table><tr><td>
+				<img src="logo.png">
+				<h1>Welcome</h1><h3>Verify</h3>
+				<a href="https://example.com/verify">Click here!</a>
+				<a href="https://example.com"><img src="home.png" alt=""></a>
+			</td></tr></table>
+		</body></html>`, "")
+
+		var rules []string
+		for _, finding := range report.Findings {
+			rules = append(rules, finding.Rule)
+		}
+		require.Equal(t, []string{
+			mailosaur.RuleDocumentLang,
+			mailosaur.RuleImageAlt,
+			mailosaur.RuleLayoutTable,
+			mailosaur.RuleLinkText,
+			mailosaur.RuleLinkName,
+			mailosaur.RuleHeadingOrder,
+			mailosaur.RuleTextAlternative,
+		}, rules)
+
+		require.Equal(t, &mailosaur.AccessibilityFinding{
+			Rule:     mailosaur.RuleLinkText,
+			Severity: mailosaur.SeverityWarning,
+			Message:  `link text "Click here!" does not describe its destination`,
+			Element:  `<a href="https://example.com/verify">`,
+		}, report.Findings[3])
+		require.Equal(t, "heading level skips from h1 to h3", report.Findings[5].Message)
+	})
+
+	t.Run("err lists only errors", func(t *testing.T) {
+		report := check(t, `<html><body><img src="logo.png"><a href="/">here</a></body></html>`, "")
+
+		var accessibilityErr *mailosaur.AccessibilityError
+		require.True(t, errors.As(report.Err(), &accessibilityErr))
+		require.Len(t, accessibilityErr.Findings, 2)
+		require.EqualError(t, report.Err(), "mailosaur: 2 accessibility error(s): "+
+			"error document-lang: html element has no lang attribute; "+
+			`error image-alt: image has no alt attribute, use alt="" for decorative images: <img src="logo.png">`)
+	})
+
+	t.Run("message without html", func(t *testing.T) {
+		t.Parallel()
+		_, err := mailosaur.CheckAccessibility(&mailosaur.Message{})
+		require.Equal(t, mailosaur.ErrNoHTML, err)
+	})
+}
@@ -0,0 +1,220 @@
+package mailosaur
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	mathrand "math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultAddressLength is the number of random characters in a generated address.
+	defaultAddressLength = 10
+	// defaultAddressAlphabet is the set of characters random address characters are drawn from.
+	defaultAddressAlphabet = "abcdefghijklmnopqrstuvwxyz"
+	// maxRandomAttempts is how many random local parts are tried before a counter is appended to force uniqueness,
+	// only reached when the configured length and alphabet allow few combinations.
+	maxRandomAttempts = 8
+	// maxIssuedAddresses is how many issued local parts a client remembers to avoid repeating them, the oldest are
+	// forgotten first.
+	maxIssuedAddresses = 10000
+
+	// DefaultAddressFormat is the format of addresses on mailosaur hosted servers, see SetAddressFormat.
+	DefaultAddressFormat = "{local}.{server}@{domain}"
+)
+
+// ErrForeignAddress is returned by ParseAddress for addresses that do not belong to the client's mailosaur server.
+var ErrForeignAddress = errors.New("mailosaur: address does not belong to the configured server")
+
+// SetMailDomain sets the mail domain of generated addresses, for servers using a custom mailosaur domain or a local
+// SMTP sink. Defaults to SMTPHost.
+func SetMailDomain(domain string) clientOption {
+	return func(c *Client) {
+		c.addresses.domain = domain
+	}
+}
+
+// SetAddressFormat sets the layout of generated addresses, where {local} is replaced with the generated local part,
+// {server} with the server id and {domain} with the mail domain, e.g. "{local}@{server}.ourdomain.test". Defaults to
+// DefaultAddressFormat. The format must contain {local}, ParseAddress fails with an error for formats that do not.
+func SetAddressFormat(format string) clientOption {
+	return func(c *Client) {
+		c.addresses.format = format
+	}
+}
+
+// SetAddressPrefix sets a prefix for the local part of every address returned by GenerateEmail.
+func SetAddressPrefix(prefix string) clientOption {
+	return func(c *Client) {
+		c.addresses.prefix = prefix
+	}
+}
+
+// SetAddressLength sets the number of random characters in every address returned by GenerateEmail, defaults to 10.
+func SetAddressLength(length int) clientOption {
+	return func(c *Client) {
+		if length > 0 {
+			c.addresses.length = length
+		}
+	}
+}
+
+// SetAddressAlphabet sets the characters random address characters are drawn from, defaults to the lower case
+// letters a to z. The alphabet must only contain characters valid in the local part of an email address.
+func SetAddressAlphabet(alphabet string) clientOption {
+	return func(c *Client) {
+		if alphabet != "" {
+			c.addresses.alphabet = alphabet
+		}
+	}
+}
+
+// SetAddressSeed makes GenerateEmail deterministic, two clients with the same seed and address settings generate the
+// same sequence of addresses. By default addresses are generated with crypto/rand.
+func SetAddressSeed(seed int64) clientOption {
+	return func(c *Client) {
+		c.addresses.rand = mathrand.New(mathrand.NewSource(seed))
+	}
+}
+
+// GenerateEmail returns a random valid email address for the configured mailosaur server. None of the last 10,000
+// addresses generated by the same client is repeated, even when generated concurrently.
+func (c *Client) GenerateEmail() string {
+	return c.generateEmail("")
+}
+
+// generateEmail returns a unique email address whose local part starts with prefix.
+func (c *Client) generateEmail(prefix string) string {
+	return strings.NewReplacer(
+		"{local}", c.addresses.next(prefix),
+		"{server}", c.serverID,
+		"{domain}", c.addresses.domain,
+	).Replace(c.addresses.format)
+}
+
+// ParseAddress splits an email address on the client's mailosaur server into its server id and local part, according
+// to the configured address format and mail domain. Addresses in any other format, on another domain or on another
+// server are rejected with an error wrapping ErrForeignAddress. If the format has no {server}, every address matching
+// it belongs to the configured server.
+func (c *Client) ParseAddress(email string) (serverID string, local string, err error) {
+	if c.addresses.patternErr != nil {
+		return "", "", c.addresses.patternErr
+	}
+
+	pattern := c.addresses.pattern
+	match := pattern.FindStringSubmatch(strings.TrimSpace(email))
+	if match == nil {
+		return "", "", fmt.Errorf("%w: %q does not match format %q", ErrForeignAddress, email, c.addresses.format)
+	}
+	// without {server} in the format the domain alone identifies the server
+	serverID = c.serverID
+	for i, name := range pattern.SubexpNames() {
+		switch name {
+		case "local":
+			local = match[i]
+		case "server":
+			serverID = match[i]
+		}
+	}
+	if !strings.EqualFold(serverID, c.serverID) {
+		return "", "", fmt.Errorf("%w: %q is on server %q", ErrForeignAddress, email, serverID)
+	}
+	return serverID, local, nil
+}
+
+// addressGenerator generates random local parts, remembering the last maxIssuedAddresses issued so that none of them
+// is repeated.
+type addressGenerator struct {
+	format   string
+	domain   string
+	prefix   string
+	length   int
+	alphabet string
+	rand     *mathrand.Rand
+
+	pattern    *regexp.Regexp
+	patternErr error
+
+	mu     sync.Mutex
+	issued map[string]struct{}
+	order  []string
+	oldest int
+}
+
+func newAddressGenerator() *addressGenerator {
+	return &addressGenerator{
+		format:   DefaultAddressFormat,
+		domain:   SMTPHost,
+		length:   defaultAddressLength,
+		alphabet: defaultAddressAlphabet,
+		issued:   map[string]struct{}{},
+	}
+}
+
+// compile builds the pattern ParseAddress matches addresses against from the configured format and domain, it is
+// called once every option has been applied. An invalid format is recorded in patternErr.
+func (g *addressGenerator) compile() {
+	if !strings.Contains(g.format, "{local}") {
+		g.patternErr = fmt.Errorf("mailosaur: address format %q has no {local}", g.format)
+		return
+	}
+	g.pattern, g.patternErr = regexp.Compile("(?i)^" + strings.NewReplacer(
+		regexp.QuoteMeta("{local}"), `(?P<local>[^@\s]+?)`,
+		regexp.QuoteMeta("{server}"), `(?P<server>[^@.\s]+)`,
+		regexp.QuoteMeta("{domain}"), regexp.QuoteMeta(g.domain),
+	).Replace(regexp.QuoteMeta(g.format)) + "$")
+}
+
+// next returns a local part that has not been issued before, made of the configured prefix, the given prefix and
+// random characters.
+func (g *addressGenerator) next(prefix string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		local := g.prefix + prefix + g.randomString()
+		if attempt >= maxRandomAttempts {
+			local += strconv.Itoa(attempt)
+		}
+		if _, ok := g.issued[local]; !ok {
+			g.remember(local)
+			return local
+		}
+	}
+}
+
+// remember records local as issued, forgetting the oldest local part once maxIssuedAddresses are remembered. Must be
+// called with mu held.
+func (g *addressGenerator) remember(local string) {
+	if len(g.order) < maxIssuedAddresses {
+		g.order = append(g.order, local)
+	} else {
+		delete(g.issued, g.order[g.oldest])
+		g.order[g.oldest] = local
+		g.oldest = (g.oldest + 1) % maxIssuedAddresses
+	}
+	g.issued[local] = struct{}{}
+}
+
+// randomString returns length characters drawn from the alphabet. Must be called with mu held.
+func (g *addressGenerator) randomString() string {
+	b := make([]byte, g.length)
+	n := big.NewInt(int64(len(g.alphabet)))
+	for i := range b {
+		if g.rand != nil {
+			b[i] = g.alphabet[g.rand.Intn(len(g.alphabet))]
+			continue
+		}
+		idx, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			panic("mailosaur: reading random bytes: " + err.Error())
+		}
+		b[i] = g.alphabet[idx.Int64()]
+	}
+	return string(b)
+}
@@ -0,0 +1,178 @@
+package mailosaur_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressGeneration(t *testing.T) {
+	localPart := func(email string) string {
+		return strings.SplitN(email, ".", 2)[0]
+	}
+
+	t.Run("uses configured prefix length and alphabet", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetAddressPrefix("ci-"),
+			mailosaur.SetAddressLength(6), mailosaur.SetAddressAlphabet("xyz"))
+
+		local := localPart(c.GenerateEmail())
+		require.True(t, strings.HasPrefix(local, "ci-"), local)
+		require.Len(t, local, 9)
+		require.Empty(t, strings.Trim(strings.TrimPrefix(local, "ci-"), "xyz"))
+	})
+
+	t.Run("seed generates reproducible addresses", func(t *testing.T) {
+		t.Parallel()
+		serverID := RandomServerID()
+		a := mailosaur.NewClient(RandomAPIKey(), serverID, mailosaur.SetAddressSeed(42))
+		b := mailosaur.NewClient(RandomAPIKey(), serverID, mailosaur.SetAddressSeed(42))
+		for i := 0; i < 5; i++ {
+			require.Equal(t, a.GenerateEmail(), b.GenerateEmail())
+		}
+	})
+
+	t.Run("never repeats an address", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetAddressLength(1),
+			mailosaur.SetAddressAlphabet("ab"))
+
+		emails := make(chan string, 200)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 25; j++ {
+					emails <- c.GenerateEmail()
+				}
+			}()
+		}
+		wg.Wait()
+		close(emails)
+
+		seen := map[string]bool{}
+		for email := range emails {
+			require.False(t, seen[email], email)
+			seen[email] = true
+		}
+		require.Len(t, seen, 200)
+	})
+
+	t.Run("keeps generating unique addresses once old ones are forgotten", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID())
+		seen := map[string]bool{}
+		for i := 0; i < 12000; i++ {
+			email := c.GenerateEmail()
+			require.False(t, seen[email], email)
+			seen[email] = true
+		}
+	})
+
+	t.Run("parallel clients do not collide", func(t *testing.T) {
+		t.Parallel()
+		serverID := RandomServerID()
+		emails := make(chan string, 100)
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				emails <- mailosaur.NewClient(RandomAPIKey(), serverID).GenerateEmail()
+			}()
+		}
+		wg.Wait()
+		close(emails)
+
+		seen := map[string]bool{}
+		for email := range emails {
+			require.False(t, seen[email], email)
+			seen[email] = true
+		}
+	})
+}
+
+func TestCustomDomains(t *testing.T) {
+	t.Run("uses configured mail domain", func(t *testing.T) {
+		t.Parallel()
+		serverID := RandomServerID()
+		c := mailosaur.NewClient(RandomAPIKey(), serverID, mailosaur.SetMailDomain("mail.example.test"))
+		require.True(t, strings.HasSuffix(c.GenerateEmail(), "."+serverID+"@mail.example.test"))
+	})
+
+	t.Run("uses configured address format", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), "abc123", mailosaur.SetAddressFormat("{local}@{server}.ourdomain.test"),
+			mailosaur.SetAddressPrefix("ci-"))
+		email := c.GenerateEmail()
+		require.True(t, strings.HasPrefix(email, "ci-"), email)
+		require.True(t, strings.HasSuffix(email, "@abc123.ourdomain.test"), email)
+	})
+
+	t.Run("parses generated addresses", func(t *testing.T) {
+		t.Parallel()
+		for _, c := range []*mailosaur.Client{
+			mailosaur.NewClient(RandomAPIKey(), "abc123"),
+			mailosaur.NewClient(RandomAPIKey(), "abc123", mailosaur.SetMailDomain("mail.example.test")),
+			mailosaur.NewClient(RandomAPIKey(), "abc123", mailosaur.SetAddressFormat("{local}@{server}.ourdomain.test")),
+			mailosaur.NewClient(RandomAPIKey(), "abc123", mailosaur.SetAddressFormat("{server}+{local}@{domain}"),
+				mailosaur.SetAddressPrefix("a.b-")),
+		} {
+			inbox := c.NewInbox(mailosaur.SetInboxName("signup"))
+			serverID, local, err := c.ParseAddress(inbox.Address())
+			require.NoError(t, err, inbox.Address())
+			require.Equal(t, "abc123", serverID)
+			require.Contains(t, local, "signup-")
+			require.Contains(t, inbox.Address(), local)
+		}
+	})
+
+	t.Run("parses addresses of format without server", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), "abc123", mailosaur.SetAddressFormat("{local}@ourdomain.test"))
+		email := c.GenerateEmail()
+		serverID, local, err := c.ParseAddress(email)
+		require.NoError(t, err, email)
+		require.Equal(t, "abc123", serverID)
+		require.Equal(t, email, local+"@ourdomain.test")
+
+		_, _, err = c.ParseAddress("someone@example.com")
+		require.True(t, errors.Is(err, mailosaur.ErrForeignAddress))
+	})
+
+	t.Run("reports format without local part", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), "abc123", mailosaur.SetAddressFormat("inbox@{server}.ourdomain.test"))
+		_, _, err := c.ParseAddress("inbox@abc123.ourdomain.test")
+		require.EqualError(t, err, `mailosaur: address format "inbox@{server}.ourdomain.test" has no {local}`)
+		require.False(t, errors.Is(err, mailosaur.ErrForeignAddress))
+	})
+
+	t.Run("parses addresses case insensitively", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), "abc123")
+		serverID, local, err := c.ParseAddress("Jane.Doe.ABC123@Mailosaur.io")
+		require.NoError(t, err)
+		require.Equal(t, "ABC123", serverID)
+		require.Equal(t, "Jane.Doe", local)
+	})
+
+	t.Run("rejects foreign addresses", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), "abc123")
+		for _, email := range []string{
+			"someone.other1@mailosaur.io",
+			"someone.abc123@example.com",
+			"abc123@mailosaur.io",
+			"not an address",
+		} {
+			_, _, err := c.ParseAddress(email)
+			require.True(t, errors.Is(err, mailosaur.ErrForeignAddress), email)
+		}
+	})
+}
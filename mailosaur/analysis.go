@@ -0,0 +1,105 @@
+package mailosaur
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CheckResult is the verdict of a single deliverability check.
+type CheckResult string
+
+// Verdicts returned by deliverability checks.
+const (
+	CheckPass    CheckResult = "Pass"
+	CheckWarning CheckResult = "Warning"
+	CheckFail    CheckResult = "Fail"
+	CheckTimeout CheckResult = "Timeout"
+)
+
+// Failed reports whether the check failed outright or could not complete. Warnings are not failures.
+func (r CheckResult) Failed() bool {
+	return r == CheckFail || r == CheckTimeout
+}
+
+// AuthenticationResult is the outcome of an SPF, DKIM or DMARC check.
+type AuthenticationResult struct {
+	Result      CheckResult `json:"result"`
+	Description string      `json:"description"`
+	// RawValue is the DNS record or header the check was based on.
+	RawValue string            `json:"rawValue"`
+	Tags     map[string]string `json:"tags"`
+}
+
+// BlockListResult is the outcome of checking the sending server against a single block list.
+type BlockListResult struct {
+	Id     string      `json:"id"`
+	Name   string      `json:"name"`
+	Result CheckResult `json:"result"`
+}
+
+// DeliverabilityReport describes how well a message authenticates and how likely it is to be delivered.
+type DeliverabilityReport struct {
+	SPF *AuthenticationResult `json:"spf"`
+	// DKIM holds the result for each DKIM signature on the message.
+	DKIM       []*AuthenticationResult `json:"dkim"`
+	DMARC      *AuthenticationResult   `json:"dmarc"`
+	BlockLists []*BlockListResult      `json:"blockLists"`
+}
+
+// DeliverabilityError lists every failing check of a DeliverabilityReport.
+type DeliverabilityError struct {
+	Failures []string
+}
+
+func (e *DeliverabilityError) Error() string {
+	return fmt.Sprintf("mailosaur: %d deliverability check(s) failed: %s", len(e.Failures), strings.Join(e.Failures, "; "))
+}
+
+// Err returns a *DeliverabilityError describing every failed check, so a single assertion covers SPF, each DKIM
+// signature, DMARC and every block list. Returns nil if no check failed, a missing SPF or DMARC result counts as a
+// failure.
+func (r *DeliverabilityReport) Err() error {
+	var failures []string
+	authFailure := func(name string, result *AuthenticationResult) {
+		if result == nil {
+			failures = append(failures, name+": no result")
+		} else if result.Result.Failed() {
+			failure := fmt.Sprintf("%s: %s", name, result.Result)
+			if result.Description != "" {
+				failure += " (" + result.Description + ")"
+			}
+			failures = append(failures, failure)
+		}
+	}
+
+	authFailure("SPF", r.SPF)
+	for i, dkim := range r.DKIM {
+		authFailure(fmt.Sprintf("DKIM signature %d", i+1), dkim)
+	}
+	authFailure("DMARC", r.DMARC)
+	for _, blockList := range r.BlockLists {
+		if blockList.Result.Failed() {
+			failures = append(failures, fmt.Sprintf("block list %s: %s", blockList.Name, blockList.Result))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return &DeliverabilityError{Failures: failures}
+}
+
+// Deliverability runs SPF, DKIM, DMARC and block list checks against a received message.
+func (c *Client) Deliverability(ctx context.Context, messageID string) (*DeliverabilityReport, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "analysis/deliverability/"+messageID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var report DeliverabilityReport
+	if err := decodeJSON(httpResp, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
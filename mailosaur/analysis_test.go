@@ -0,0 +1,103 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliverability(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("calls deliverability endpoint", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "deliverability_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msgID := RandomMessageID()
+		_, err := ts.client.Deliverability(context.Background(), msgID)
+		require.NoError(t, err)
+		require.Equal(t, "/analysis/deliverability/"+msgID, ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodGet, ts.recvReq.Method)
+	})
+
+	t.Run("returns typed report", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "deliverability_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		report, err := ts.client.Deliverability(context.Background(), RandomMessageID())
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckPass, report.SPF.Result)
+		require.Len(t, report.DKIM, 2)
+		require.Equal(t, mailosaur.CheckFail, report.DKIM[1].Result)
+		require.Equal(t, "mailer.example.net", report.DKIM[1].Tags["d"])
+		require.Equal(t, mailosaur.CheckWarning, report.DMARC.Result)
+		require.Len(t, report.BlockLists, 2)
+		require.Equal(t, mailosaur.CheckTimeout, report.BlockLists[1].Result)
+	})
+
+	t.Run("returns api error", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusNotFound})
+
+		report, err := ts.client.Deliverability(context.Background(), RandomMessageID())
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		require.Nil(t, report)
+	})
+
+	t.Run("err lists every failing check", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "deliverability_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		report, err := ts.client.Deliverability(context.Background(), RandomMessageID())
+		require.NoError(t, err)
+
+		var deliverabilityErr *mailosaur.DeliverabilityError
+		require.True(t, errors.As(report.Err(), &deliverabilityErr))
+		require.Equal(t, []string{
+			"DKIM signature 2: Fail (Body hash did not verify)",
+			"block list Barracuda: Timeout",
+		}, deliverabilityErr.Failures)
+	})
+}
+
+func TestDeliverabilityReportErr(t *testing.T) {
+	pass := &mailosaur.AuthenticationResult{Result: mailosaur.CheckPass}
+
+	t.Run("nil when every check passes", func(t *testing.T) {
+		report := &mailosaur.DeliverabilityReport{
+			SPF:        pass,
+			DKIM:       []*mailosaur.AuthenticationResult{pass},
+			DMARC:      &mailosaur.AuthenticationResult{Result: mailosaur.CheckWarning},
+			BlockLists: []*mailosaur.BlockListResult{{Name: "Spamhaus", Result: mailosaur.CheckPass}},
+		}
+		require.NoError(t, report.Err())
+	})
+
+	t.Run("missing results fail", func(t *testing.T) {
+		err := (&mailosaur.DeliverabilityReport{}).Err()
+		require.EqualError(t, err, "mailosaur: 2 deliverability check(s) failed: SPF: no result; DMARC: no result")
+	})
+}
@@ -0,0 +1,192 @@
+package mailosaur
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultConcurrency is the number of requests batch operations make in parallel unless configured otherwise.
+const defaultConcurrency = 4
+
+// batchOptions holds the settings shared by operations that act on many messages at once.
+type batchOptions struct {
+	concurrency int
+	dryRun      bool
+}
+
+// batchOption configures batch operations such as GetMessages.
+type batchOption func(*batchOptions)
+
+// SetConcurrency sets the maximum number of requests a batch operation makes in parallel, defaults to 4.
+func SetConcurrency(concurrency int) batchOption {
+	return func(o *batchOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// SetDryRun makes destructive batch operations, such as DeleteMatching, report what they would do without doing it.
+func SetDryRun(dryRun bool) batchOption {
+	return func(o *batchOptions) {
+		o.dryRun = dryRun
+	}
+}
+
+func applyBatchOptions(options []batchOption) *batchOptions {
+	o := &batchOptions{concurrency: defaultConcurrency}
+	for _, opt := range options {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	return o
+}
+
+// MessageError records the failure of a batch operation for a single message.
+type MessageError struct {
+	MessageID string
+	Err       error
+}
+
+func (e *MessageError) Error() string {
+	return e.MessageID + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *MessageError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by batch operations when one or more messages failed, the remaining messages succeeded.
+type BatchError struct {
+	Errors []*MessageError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("mailosaur: %d message(s) failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// forEach calls fn for every index from 0 to n-1 using at most concurrency goroutines. Calls not yet started when ctx
+// is cancelled fail with the context's error. The error of each call is returned at its index, nil if it succeeded.
+func forEach(ctx context.Context, n int, concurrency int, fn func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = fn(ctx, i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return errs
+}
+
+// batchError returns a *BatchError for the failed message ids, errs holds the error of each id at its index. Returns
+// nil if every id succeeded.
+func batchError(ids []string, errs []error) error {
+	var batchErr BatchError
+	for i, err := range errs {
+		if err != nil {
+			batchErr.Errors = append(batchErr.Errors, &MessageError{MessageID: ids[i], Err: err})
+		}
+	}
+	if len(batchErr.Errors) > 0 {
+		return &batchErr
+	}
+	return nil
+}
+
+// GetMessages retrieves the detail for many messages in parallel, for example the results of SearchMessages. Messages
+// are returned in the same order as ids. If any message could not be retrieved its entry is nil and a *BatchError
+// describing every failure is returned alongside the messages that were retrieved, e.g. an *APIError for a message
+// that does not exist. Every request counts against the client's rate limit, see SetRateLimit.
+func (c *Client) GetMessages(ctx context.Context, ids []string, options ...batchOption) ([]*Message, error) {
+	o := applyBatchOptions(options)
+	messages := make([]*Message, len(ids))
+	errs := forEach(ctx, len(ids), o.concurrency, func(ctx context.Context, i int) error {
+		msg, err := c.getMessage(ctx, ids[i])
+		if err != nil {
+			return err
+		}
+		messages[i] = msg
+		return nil
+	})
+	return messages, batchError(ids, errs)
+}
+
+// searchPageSize is the number of results requested per page when paging through every search result.
+const searchPageSize = 100
+
+// searchAll pages through every message summary matching lookup.
+func (c *Client) searchAll(ctx context.Context, lookup *SearchMessagesLookup) ([]*MessageSummary, error) {
+	var all []*MessageSummary
+	for page := 0; ; page++ {
+		items, err := c.searchMessages(ctx, lookup, SetPage(page), SetItemsPerPage(searchPageSize))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < searchPageSize {
+			return all, nil
+		}
+	}
+}
+
+// DeleteResult reports the outcome of DeleteMatching.
+type DeleteResult struct {
+	// Matched lists every message that matched the search, and that was deleted unless DryRun is set.
+	Matched []*MessageSummary
+	// Deleted is the number of messages successfully deleted, always zero for a dry run.
+	Deleted int
+	// Failed lists the messages that could not be deleted.
+	Failed []*MessageError
+	DryRun bool
+}
+
+// DeleteMatching permanently deletes every message matching lookup, leaving the rest of the server's messages in place.
+// This lets test suites sharing a server clean up only their own mail. All pages of search results are collected
+// before deleting, then messages are deleted in parallel, see SetConcurrency. With SetDryRun nothing is deleted and
+// the result lists what would have been. If any deletion fails a *BatchError is returned along with the result.
+func (c *Client) DeleteMatching(ctx context.Context, lookup *SearchMessagesLookup, options ...batchOption) (*DeleteResult, error) {
+	o := applyBatchOptions(options)
+	matched, err := c.searchAll(ctx, lookup)
+	if err != nil {
+		return nil, err
+	}
+	result := &DeleteResult{Matched: matched, DryRun: o.dryRun}
+	if o.dryRun {
+		return result, nil
+	}
+
+	ids := make([]string, len(matched))
+	for i, summary := range matched {
+		ids[i] = summary.Id
+	}
+	err = batchError(ids, forEach(ctx, len(ids), o.concurrency, func(ctx context.Context, i int) error {
+		return c.deleteMessage(ctx, ids[i])
+	}))
+	result.Deleted = len(ids)
+	if batchErr, ok := err.(*BatchError); ok {
+		result.Failed = batchErr.Errors
+		result.Deleted -= len(batchErr.Errors)
+	}
+	return result, err
+}
@@ -0,0 +1,268 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+// NewMessagesHTTPServer starts an http server that responds to GET /messages/{id} with a message carrying that id,
+// except for ids prefixed with "fail" which receive an invalid body and ids prefixed with "missing" which receive a 404
+// error. The returned pointer records the highest number of requests that were in flight at once.
+func NewMessagesHTTPServer(t *testing.T, delay time.Duration) (*httptest.Server, *int64) {
+	var inFlight, maxInFlight int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+
+		id := strings.TrimPrefix(r.URL.Path, "/messages/")
+		if strings.HasPrefix(id, "fail") {
+			_, err := w.Write([]byte("not json"))
+			require.NoError(t, err)
+			return
+		}
+		if strings.HasPrefix(id, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			_, err := w.Write([]byte(`{"message": "Message not found"}`))
+			require.NoError(t, err)
+			return
+		}
+		_, err := fmt.Fprintf(w, `{"id": %q}`, id)
+		require.NoError(t, err)
+	}))
+	return s, &maxInFlight
+}
+
+// FakeSearchServer is a fake mailosaur API holding a fixed set of messages that can be searched page by page and
+// deleted. Deleting a message whose id is prefixed with "fail" drops the connection, and one prefixed with "error"
+// receives a 500 error.
+type FakeSearchServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	ids      []string
+	deleted  []string
+	searches int
+}
+
+// NewFakeSearchServer starts a FakeSearchServer holding messages with the given ids.
+func NewFakeSearchServer(t *testing.T, ids []string) *FakeSearchServer {
+	f := &FakeSearchServer{ids: ids}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/messages/search":
+			f.searches++
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			perPage, _ := strconv.Atoi(r.URL.Query().Get("itemsPerPage"))
+			items := []string{}
+			for i := page * perPage; i < len(f.ids) && i < (page+1)*perPage; i++ {
+				items = append(items, fmt.Sprintf(`{"id": %q}`, f.ids[i]))
+			}
+			_, err := fmt.Fprintf(w, `{"items": [%s]}`, strings.Join(items, ","))
+			require.NoError(t, err)
+		case r.Method == http.MethodDelete:
+			id := strings.TrimPrefix(r.URL.Path, "/messages/")
+			if strings.HasPrefix(id, "fail") {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				require.NoError(t, err)
+				conn.Close()
+				return
+			}
+			if strings.HasPrefix(id, "error") {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			f.deleted = append(f.deleted, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return f
+}
+
+// Deleted returns the ids of the messages deleted so far.
+func (f *FakeSearchServer) Deleted() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+func TestGetMessages(t *testing.T) {
+	t.Run("returns messages in input order", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewMessagesHTTPServer(t, 0)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		ids := make([]string, 20)
+		for i := range ids {
+			ids[i] = RandomMessageID()
+		}
+		messages, err := c.GetMessages(context.Background(), ids)
+		require.NoError(t, err)
+		require.Len(t, messages, len(ids))
+		for i, msg := range messages {
+			require.Equal(t, ids[i], msg.Id)
+		}
+	})
+
+	t.Run("limits concurrency", func(t *testing.T) {
+		t.Parallel()
+		s, maxInFlight := NewMessagesHTTPServer(t, 10*time.Millisecond)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		ids := make([]string, 12)
+		for i := range ids {
+			ids[i] = RandomMessageID()
+		}
+		_, err := c.GetMessages(context.Background(), ids, mailosaur.SetConcurrency(3))
+		require.NoError(t, err)
+		require.LessOrEqual(t, atomic.LoadInt64(maxInFlight), int64(3))
+		require.Greater(t, atomic.LoadInt64(maxInFlight), int64(1))
+	})
+
+	t.Run("collects per message errors", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewMessagesHTTPServer(t, 0)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		ids := []string{"good-1", "fail-1", "good-2", "fail-2", "missing-1"}
+		messages, err := c.GetMessages(context.Background(), ids)
+		require.Error(t, err)
+
+		var batchErr *mailosaur.BatchError
+		require.True(t, errors.As(err, &batchErr))
+		require.Len(t, batchErr.Errors, 3)
+		require.Equal(t, "fail-1", batchErr.Errors[0].MessageID)
+		require.Equal(t, "fail-2", batchErr.Errors[1].MessageID)
+		require.Equal(t, "missing-1", batchErr.Errors[2].MessageID)
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(batchErr.Errors[2], &apiErr))
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+		require.Equal(t, "good-1", messages[0].Id)
+		require.Nil(t, messages[1])
+		require.Equal(t, "good-2", messages[2].Id)
+		require.Nil(t, messages[3])
+		require.Nil(t, messages[4])
+	})
+
+	t.Run("respects rate limit", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewMessagesHTTPServer(t, 0)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetRateLimit(50, 1))
+
+		start := time.Now()
+		_, err := c.GetMessages(context.Background(), []string{"a", "b", "c", "d", "e"}, mailosaur.SetConcurrency(5))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(80*time.Millisecond))
+	})
+
+	t.Run("stops on context cancellation", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewMessagesHTTPServer(t, 0)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		messages, err := c.GetMessages(ctx, []string{"a", "b"})
+		var batchErr *mailosaur.BatchError
+		require.True(t, errors.As(err, &batchErr))
+		require.Len(t, batchErr.Errors, 2)
+		require.True(t, errors.Is(batchErr.Errors[0], context.Canceled))
+		require.Equal(t, []*mailosaur.Message{nil, nil}, messages)
+	})
+}
+
+func TestDeleteMatching(t *testing.T) {
+	messageIDs := func(n int) []string {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = RandomMessageID()
+		}
+		return ids
+	}
+
+	t.Run("deletes every page of matching messages", func(t *testing.T) {
+		t.Parallel()
+		ids := messageIDs(250)
+		f := NewFakeSearchServer(t, ids)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(f.URL))
+
+		result, err := c.DeleteMatching(context.Background(), &mailosaur.SearchMessagesLookup{SentTo: "a@b.c"},
+			mailosaur.SetConcurrency(8))
+		require.NoError(t, err)
+		require.Len(t, result.Matched, 250)
+		require.Equal(t, 250, result.Deleted)
+		require.Empty(t, result.Failed)
+		require.ElementsMatch(t, ids, f.Deleted())
+		require.Equal(t, 3, f.searches)
+	})
+
+	t.Run("dry run deletes nothing", func(t *testing.T) {
+		t.Parallel()
+		f := NewFakeSearchServer(t, messageIDs(5))
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(f.URL))
+
+		result, err := c.DeleteMatching(context.Background(), &mailosaur.SearchMessagesLookup{}, mailosaur.SetDryRun(true))
+		require.NoError(t, err)
+		require.True(t, result.DryRun)
+		require.Len(t, result.Matched, 5)
+		require.Zero(t, result.Deleted)
+		require.Empty(t, f.Deleted())
+	})
+
+	t.Run("reports failures", func(t *testing.T) {
+		t.Parallel()
+		ids := []string{"good-1", "fail-1", "good-2", "error-1"}
+		f := NewFakeSearchServer(t, ids)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(f.URL))
+
+		result, err := c.DeleteMatching(context.Background(), &mailosaur.SearchMessagesLookup{})
+		var batchErr *mailosaur.BatchError
+		require.True(t, errors.As(err, &batchErr))
+		require.Equal(t, 2, result.Deleted)
+		require.Len(t, result.Failed, 2)
+		require.Equal(t, "fail-1", result.Failed[0].MessageID)
+		require.Equal(t, "error-1", result.Failed[1].MessageID)
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(result.Failed[1], &apiErr))
+		require.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		require.ElementsMatch(t, []string{"good-1", "good-2"}, f.Deleted())
+	})
+
+	t.Run("fails when search is rejected", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewTestHTTPServer(t, &TestResponse{StatusCode: http.StatusUnauthorized})
+		defer s.Close()
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		result, err := c.DeleteMatching(context.Background(), &mailosaur.SearchMessagesLookup{})
+		require.Nil(t, result)
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	})
+}
@@ -0,0 +1,159 @@
+package mailosaur
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Call without contacting the mailosaur API while the client's circuit breaker is open.
+var ErrCircuitOpen = errors.New("mailosaur: circuit breaker is open, the API is failing")
+
+// CircuitState describes the state of a client's circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through, this is the normal state.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every request immediately with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to test whether the API has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// SetCircuitBreaker enables a circuit breaker that opens after threshold consecutive failed requests, where a failure
+// is a transport error or a 5xx response. While open, requests fail fast with ErrCircuitOpen. Once cooldown has
+// elapsed a single probe request is let through, closing the circuit if it succeeds and re-opening it if it fails.
+func SetCircuitBreaker(threshold int, cooldown time.Duration) clientOption {
+	return func(c *Client) {
+		c.breaker = &circuitBreaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			now:       time.Now,
+		}
+	}
+}
+
+// SetCircuitStateHook registers a function called whenever the circuit breaker changes state, e.g. to log outages.
+// It has no effect unless SetCircuitBreaker is also used.
+func SetCircuitStateHook(hook func(from, to CircuitState)) clientOption {
+	return func(c *Client) {
+		c.circuitHook = hook
+	}
+}
+
+// CircuitState returns the current state of the client's circuit breaker, CircuitClosed if none is configured.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.currentState()
+}
+
+// circuitBreaker tracks consecutive request failures and decides whether requests may be sent.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	hook      func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// currentState returns the breaker state, reporting an open breaker whose cooldown has elapsed as half-open.
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent. Every allowed request must be followed by a call to
+// record with its outcome.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	from := b.state
+	err := b.allowLocked()
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return err
+}
+
+func (b *circuitBreaker) allowLocked() error {
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a request that allow let through.
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	from := b.state
+	b.recordLocked(success)
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+func (b *circuitBreaker) recordLocked(success bool) {
+	if success {
+		b.failures = 0
+		b.probing = false
+		b.state = CircuitClosed
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.probing = false
+		b.openedAt = b.now()
+		b.state = CircuitOpen
+	}
+}
+
+// release gives up the slot taken by allow without recording an outcome, allowing another probe if half-open.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// notify calls the state hook if the breaker moved between states.
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.hook != nil {
+		b.hook(from, to)
+	}
+}
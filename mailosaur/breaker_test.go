@@ -0,0 +1,110 @@
+package mailosaur_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	type testSetup struct {
+		resp        *TestResponse
+		client      *mailosaur.Client
+		transitions []string
+	}
+
+	setup := func(t *testing.T, threshold int, cooldown time.Duration) *testSetup {
+		t.Parallel()
+		ts := &testSetup{resp: &TestResponse{StatusCode: http.StatusServiceUnavailable}}
+		s, _ := NewTestHTTPServer(t, ts.resp)
+		ts.client = mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetCircuitBreaker(threshold, cooldown),
+			mailosaur.SetCircuitStateHook(func(from, to mailosaur.CircuitState) {
+				ts.transitions = append(ts.transitions, from.String()+"->"+to.String())
+			}))
+		return ts
+	}
+
+	t.Run("stays closed below threshold", func(t *testing.T) {
+		ts := setup(t, 3, time.Minute)
+		for i := 0; i < 2; i++ {
+			_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+			require.NoError(t, err)
+		}
+		require.Equal(t, mailosaur.CircuitClosed, ts.client.CircuitState())
+	})
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		ts := setup(t, 2, time.Minute)
+		for i := 0; i < 2; i++ {
+			_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+			require.NoError(t, err)
+		}
+		require.Equal(t, mailosaur.CircuitOpen, ts.client.CircuitState())
+
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.Equal(t, mailosaur.ErrCircuitOpen, err)
+		require.Equal(t, []string{"closed->open"}, ts.transitions)
+	})
+
+	t.Run("success resets failure count", func(t *testing.T) {
+		ts := setup(t, 2, time.Minute)
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		ts.resp.StatusCode = http.StatusOK
+		_, err = ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		ts.resp.StatusCode = http.StatusInternalServerError
+		_, err = ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CircuitClosed, ts.client.CircuitState())
+	})
+
+	t.Run("client errors do not count as failures", func(t *testing.T) {
+		ts := setup(t, 1, time.Minute)
+		ts.resp.StatusCode = http.StatusNotFound
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CircuitClosed, ts.client.CircuitState())
+	})
+
+	t.Run("closes after successful probe", func(t *testing.T) {
+		ts := setup(t, 1, 10*time.Millisecond)
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, mailosaur.CircuitHalfOpen, ts.client.CircuitState())
+
+		ts.resp.StatusCode = http.StatusOK
+		_, err = ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CircuitClosed, ts.client.CircuitState())
+		require.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, ts.transitions)
+	})
+
+	t.Run("reopens after failed probe", func(t *testing.T) {
+		ts := setup(t, 1, 10*time.Millisecond)
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CircuitOpen, ts.client.CircuitState())
+		_, err = ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.Equal(t, mailosaur.ErrCircuitOpen, err)
+	})
+
+	t.Run("opens on transport errors", func(t *testing.T) {
+		t.Parallel()
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL("http://127.0.0.1:0"),
+			mailosaur.SetCircuitBreaker(1, time.Minute))
+		_, err := c.Call(http.MethodGet, "path", nil, nil)
+		require.Error(t, err)
+		require.NotEqual(t, mailosaur.ErrCircuitOpen, err)
+		require.Equal(t, mailosaur.CircuitOpen, c.CircuitState())
+	})
+}
@@ -0,0 +1,159 @@
+package mailosaur
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	messageCacheKey    = "message:"
+	attachmentCacheKey = "attachment:"
+)
+
+// CacheStats reports the effectiveness of a client's response cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+// SetCache enables an in-memory LRU cache for GetMessage responses and downloaded attachments. Received messages never
+// change, so repeated lookups of the same id are served from memory. The cache holds at most maxBytes of response data,
+// evicting the least recently used entries first, and entries expire after ttl, a ttl of zero never expires entries.
+// DeleteMessage invalidates the message and every cached attachment, DeleteMessages empties the cache.
+func SetCache(maxBytes int64, ttl time.Duration) clientOption {
+	return func(c *Client) {
+		c.cache = newLRUCache(maxBytes, ttl)
+	}
+}
+
+// CacheStats returns hit and miss counts and the current size of the client's cache, all zero if no cache is enabled.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
+
+// invalidateMessage removes a message from the client's cache along with every cached attachment. Attachments are
+// cached by id alone, which of them belong to the message is only known while the message itself is cached.
+func (c *Client) invalidateMessage(messageID string) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.remove(messageCacheKey + messageID)
+	c.cache.removePrefix(attachmentCacheKey)
+}
+
+type cacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// lruCache is a concurrency safe, byte bounded, least recently used cache of response bodies.
+type lruCache struct {
+	maxBytes int64
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	ll     *list.List
+	items  map[string]*list.Element
+	bytes  int64
+	counts CacheStats
+}
+
+func newLRUCache(maxBytes int64, ttl time.Duration) *lruCache {
+	return &lruCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		now:      time.Now,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// get returns the cached value for key, counting the lookup as a hit or miss.
+func (c *lruCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if ok {
+		entry := el.Value.(*cacheEntry)
+		if c.ttl > 0 && c.now().After(entry.expires) {
+			c.removeElement(el)
+			ok = false
+		} else {
+			c.ll.MoveToFront(el)
+			c.counts.Hits++
+			return entry.value, true
+		}
+	}
+	c.counts.Misses++
+	return nil, false
+}
+
+// set stores value under key, evicting least recently used entries until the cache fits its byte budget. Values
+// larger than the whole budget are not cached.
+func (c *lruCache) set(key string, value []byte) {
+	size := int64(len(value))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	el := c.ll.PushFront(&cacheEntry{key: key, value: value, expires: c.now().Add(c.ttl)})
+	c.items[key] = el
+	c.bytes += size
+
+	for c.bytes > c.maxBytes {
+		c.removeElement(c.ll.Back())
+		c.counts.Evictions++
+	}
+}
+
+// remove deletes key from the cache if present.
+func (c *lruCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// removePrefix deletes every key starting with prefix.
+func (c *lruCache) removePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+}
+
+func (c *lruCache) removeElement(el *list.Element) {
+	entry := c.ll.Remove(el).(*cacheEntry)
+	delete(c.items, entry.key)
+	c.bytes -= int64(len(entry.value))
+}
+
+func (c *lruCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.counts
+	stats.Entries = c.ll.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
@@ -0,0 +1,183 @@
+package mailosaur_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+// NewCountingHTTPServer starts an http server that responds to GET requests with body and to every other method with
+// no content, returning a pointer to the number of GET requests received.
+func NewCountingHTTPServer(t *testing.T, body []byte) (*httptest.Server, *int64) {
+	var gets int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		atomic.AddInt64(&gets, 1)
+		_, err := w.Write(body)
+		require.NoError(t, err)
+	}))
+	return s, &gets
+}
+
+func TestCache(t *testing.T) {
+	type testSetup struct {
+		gets   *int64
+		client *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, body []byte) *testSetup {
+		t.Parallel()
+		s, gets := NewCountingHTTPServer(t, body)
+		return &testSetup{
+			gets:   gets,
+			client: mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL), mailosaur.SetCache(1<<20, time.Minute)),
+		}
+	}
+
+	t.Run("serves repeated GetMessage from cache", func(t *testing.T) {
+		ts := setup(t, LoadTestData(t, "get_message_success.json"))
+		msgID := RandomMessageID()
+		first, err := ts.client.GetMessage(msgID)
+		require.NoError(t, err)
+		second, err := ts.client.GetMessage(msgID)
+		require.NoError(t, err)
+
+		require.Equal(t, first, second)
+		require.EqualValues(t, 1, atomic.LoadInt64(ts.gets))
+		stats := ts.client.CacheStats()
+		require.EqualValues(t, 1, stats.Hits)
+		require.EqualValues(t, 1, stats.Misses)
+		require.Equal(t, 1, stats.Entries)
+	})
+
+	t.Run("DeleteMessage invalidates message", func(t *testing.T) {
+		ts := setup(t, LoadTestData(t, "get_message_success.json"))
+		msgID := RandomMessageID()
+		_, err := ts.client.GetMessage(msgID)
+		require.NoError(t, err)
+		require.NoError(t, ts.client.DeleteMessage(msgID))
+		_, err = ts.client.GetMessage(msgID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, atomic.LoadInt64(ts.gets))
+	})
+
+	t.Run("DeleteMessage invalidates message cached while deleting", func(t *testing.T) {
+		t.Parallel()
+		var (
+			c    *mailosaur.Client
+			gets int64
+		)
+		msgID := RandomMessageID()
+		body := LoadTestData(t, "get_message_success.json")
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete {
+				// a concurrent reader fetches the message before the delete completes
+				_, err := c.GetMessage(msgID)
+				require.NoError(t, err)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			atomic.AddInt64(&gets, 1)
+			_, err := w.Write(body)
+			require.NoError(t, err)
+		}))
+		defer s.Close()
+		c = mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetCache(1<<20, time.Minute))
+
+		require.NoError(t, c.DeleteMessage(msgID))
+		_, err := c.GetMessage(msgID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, atomic.LoadInt64(&gets))
+	})
+
+	t.Run("DeleteMessage invalidates attachments of uncached message", func(t *testing.T) {
+		ts := setup(t, []byte("attachment content"))
+		attachmentID := RandomMessageID()
+		_, err := ts.client.DownloadAttachment(context.Background(), attachmentID)
+		require.NoError(t, err)
+		require.NoError(t, ts.client.DeleteMessage(RandomMessageID()))
+		_, err = ts.client.DownloadAttachment(context.Background(), attachmentID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, atomic.LoadInt64(ts.gets))
+	})
+
+	t.Run("DeleteMessages invalidates everything", func(t *testing.T) {
+		ts := setup(t, LoadTestData(t, "get_message_success.json"))
+		_, err := ts.client.GetMessage(RandomMessageID())
+		require.NoError(t, err)
+		_, err = ts.client.DownloadAttachment(context.Background(), RandomMessageID())
+		require.NoError(t, err)
+		require.NoError(t, ts.client.DeleteMessages())
+		require.Equal(t, 0, ts.client.CacheStats().Entries)
+	})
+
+	t.Run("caches attachment downloads", func(t *testing.T) {
+		ts := setup(t, []byte("attachment content"))
+		attachmentID := RandomMessageID()
+		for i := 0; i < 3; i++ {
+			content, err := ts.client.DownloadAttachment(context.Background(), attachmentID)
+			require.NoError(t, err)
+			require.Equal(t, "attachment content", string(content))
+		}
+		require.EqualValues(t, 1, atomic.LoadInt64(ts.gets))
+		require.EqualValues(t, 2, ts.client.CacheStats().Hits)
+	})
+
+	t.Run("expires entries after ttl", func(t *testing.T) {
+		t.Parallel()
+		s, gets := NewCountingHTTPServer(t, LoadTestData(t, "get_message_success.json"))
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetCache(1<<20, 10*time.Millisecond))
+		msgID := RandomMessageID()
+		_, err := c.GetMessage(msgID)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = c.GetMessage(msgID)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, atomic.LoadInt64(gets))
+	})
+
+	t.Run("evicts least recently used entries over budget", func(t *testing.T) {
+		t.Parallel()
+		content := strings.Repeat("a", 100)
+		s, gets := NewCountingHTTPServer(t, []byte(content))
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetCache(250, time.Minute))
+		for _, id := range []string{"a", "b", "a", "c", "a"} {
+			_, err := c.DownloadAttachment(context.Background(), id)
+			require.NoError(t, err)
+		}
+		stats := c.CacheStats()
+		require.EqualValues(t, 1, stats.Evictions)
+		require.EqualValues(t, 200, stats.Bytes)
+		require.EqualValues(t, 3, atomic.LoadInt64(gets))
+
+		_, err := c.DownloadAttachment(context.Background(), "b")
+		require.NoError(t, err)
+		require.EqualValues(t, 4, atomic.LoadInt64(gets))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+		s, gets := NewCountingHTTPServer(t, LoadTestData(t, "get_message_success.json"))
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+		msgID := RandomMessageID()
+		for i := 0; i < 2; i++ {
+			_, err := c.GetMessage(msgID)
+			require.NoError(t, err)
+		}
+		require.EqualValues(t, 2, atomic.LoadInt64(gets))
+		require.Equal(t, mailosaur.CacheStats{}, c.CacheStats())
+	})
+}
@@ -0,0 +1,75 @@
+package mailosaur
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Device is a virtual security device, an authenticator app run by mailosaur for testing multi-factor authentication.
+type Device struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// OTP is a one-time password generated by a virtual security device.
+type OTP struct {
+	Code    string    `json:"code"`
+	Expires time.Time `json:"expires"`
+}
+
+// ListDevices returns every virtual security device on the account.
+func (c *Client) ListDevices(ctx context.Context) ([]*Device, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "devices", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct{ Items []*Device }
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// CreateDevice creates a virtual security device from the base32 shared secret shown when enrolling an authenticator
+// app, typically encoded in the enrollment QR code.
+func (c *Client) CreateDevice(ctx context.Context, name string, sharedSecret string) (*Device, error) {
+	if name == "" || sharedSecret == "" {
+		return nil, errors.New("mailosaur: creating a device requires a name and shared secret")
+	}
+	httpResp, err := c.do(ctx, http.MethodPost, "devices", nil, map[string]string{
+		"name":         name,
+		"sharedSecret": sharedSecret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var device Device
+	if err := decodeJSON(httpResp, &device); err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// DeleteDevice permanently deletes a virtual security device.
+func (c *Client) DeleteDevice(ctx context.Context, deviceID string) error {
+	httpResp, err := c.do(ctx, http.MethodDelete, "devices/"+deviceID, nil, nil)
+	if err != nil {
+		return err
+	}
+	return httpResp.Body.Close()
+}
+
+// DeviceOTP returns the current one-time password of a virtual security device.
+func (c *Client) DeviceOTP(ctx context.Context, deviceID string) (*OTP, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "devices/"+deviceID+"/otp", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var otp OTP
+	if err := decodeJSON(httpResp, &otp); err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
@@ -0,0 +1,103 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevices(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("lists devices", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "list_devices_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		devices, err := ts.client.ListDevices(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "/devices", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodGet, ts.recvReq.Method)
+		require.Len(t, devices, 1)
+		require.Equal(t, "My test device", devices[0].Name)
+	})
+
+	t.Run("creates device", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "device_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		device, err := ts.client.CreateDevice(context.Background(), "My test device", "ONSWG4TFOQYTEMY=")
+		require.NoError(t, err)
+		require.Equal(t, "/devices", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodPost, ts.recvReq.Method)
+		require.JSONEq(t, `{"name": "My test device", "sharedSecret": "ONSWG4TFOQYTEMY="}`, string(ts.recvReq.Body))
+		require.Equal(t, "02af7d3c-b6f4-4ac8-9b4d-d1f6a4b39e7b", device.Id)
+	})
+
+	t.Run("create requires name and secret", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusOK})
+
+		_, err := ts.client.CreateDevice(context.Background(), "My test device", "")
+		require.Error(t, err)
+		require.Empty(t, ts.recvReq.Method)
+	})
+
+	t.Run("deletes device", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusNoContent})
+
+		deviceID := RandomMessageID()
+		require.NoError(t, ts.client.DeleteDevice(context.Background(), deviceID))
+		require.Equal(t, "/devices/"+deviceID, ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodDelete, ts.recvReq.Method)
+	})
+
+	t.Run("returns device otp", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "device_otp_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		deviceID := RandomMessageID()
+		otp, err := ts.client.DeviceOTP(context.Background(), deviceID)
+		require.NoError(t, err)
+		require.Equal(t, "/devices/"+deviceID+"/otp", ts.recvReq.URL.Path)
+		require.Equal(t, "564214", otp.Code)
+		require.Equal(t, 30, otp.Expires.Second())
+	})
+
+	t.Run("returns no otp for invalid response", func(t *testing.T) {
+		ts := setup(t, &TestResponse{Body: []byte("not json"), StatusCode: http.StatusOK})
+
+		otp, err := ts.client.DeviceOTP(context.Background(), RandomMessageID())
+		require.Error(t, err)
+		require.Nil(t, otp)
+	})
+
+	t.Run("delete returns api error", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusNotFound})
+
+		err := ts.client.DeleteDevice(context.Background(), RandomMessageID())
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+}
@@ -0,0 +1,461 @@
+package mailosaur
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1" // registers the hashes used by DKIM algorithms
+	_ "crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DKIM verification errors, wrapped by DKIMResult.Err.
+var (
+	// ErrNoDKIMSignature is returned by VerifyDKIM when the message has no DKIM-Signature header.
+	ErrNoDKIMSignature = errors.New("mailosaur: message has no DKIM-Signature header")
+	// ErrDKIMBodyHash means the message body was changed after it was signed.
+	ErrDKIMBodyHash = errors.New("mailosaur: DKIM body hash did not verify")
+	// ErrDKIMSignature means the signed headers were changed after signing, or were signed with a different key.
+	ErrDKIMSignature = errors.New("mailosaur: DKIM signature did not verify")
+	// ErrDKIMExpired means the signature's x= expiry time has passed.
+	ErrDKIMExpired = errors.New("mailosaur: DKIM signature has expired")
+)
+
+// DKIMResolver looks up the DNS TXT records publishing DKIM public keys, named "<selector>._domainkey.<domain>".
+type DKIMResolver interface {
+	LookupTXT(name string) ([]string, error)
+}
+
+// DNSResolver resolves DKIM public keys using the system's DNS resolver.
+var DNSResolver DKIMResolver = dnsResolver{}
+
+type dnsResolver struct{}
+
+func (dnsResolver) LookupTXT(name string) ([]string, error) {
+	return net.LookupTXT(name)
+}
+
+// DKIMKeys resolves DKIM public keys from a fixed set of TXT records, e.g.
+// DKIMKeys{"mail._domainkey.example.com": "v=DKIM1; k=rsa; p=MIIBIjANBg..."}, so tests need not depend on DNS.
+type DKIMKeys map[string]string
+
+// LookupTXT returns the record published under name, names are matched case insensitively.
+func (k DKIMKeys) LookupTXT(name string) ([]string, error) {
+	for recordName, record := range k {
+		if strings.EqualFold(recordName, name) {
+			return []string{record}, nil
+		}
+	}
+	return nil, fmt.Errorf("mailosaur: no DKIM key record for %s", name)
+}
+
+// DKIMResult is the outcome of verifying a single DKIM-Signature header.
+type DKIMResult struct {
+	// Result is CheckPass if both the body hash and signature verified, CheckFail otherwise.
+	Result CheckResult
+	// Err explains why verification failed, it is ErrDKIMBodyHash or ErrDKIMSignature when the message was
+	// changed after signing.
+	Err error
+
+	Domain                 string
+	Selector               string
+	Algorithm              string
+	HeaderCanonicalization string
+	BodyCanonicalization   string
+	// SignedHeaders lists the header fields covered by the signature, in signing order.
+	SignedHeaders []string
+	// BodyHash is the base64 body hash claimed by the signature, ComputedBodyHash is the hash of the body received.
+	BodyHash         string
+	ComputedBodyHash string
+}
+
+// VerifyDKIM verifies every DKIM signature on a raw RFC 6376 message, such as one returned by DownloadEML, looking up
+// public keys with resolver. It returns one result per DKIM-Signature header in the order they appear, or
+// ErrNoDKIMSignature if there are none. Signatures that fail to verify are reported in their results, not as an error.
+// As RFC 6376 section 5.4 requires, signatures that do not sign the From header fail, as do signatures whose x= expiry
+// has passed.
+func VerifyDKIM(eml io.Reader, resolver DKIMResolver) ([]*DKIMResult, error) {
+	data, err := ioutil.ReadAll(eml)
+	if err != nil {
+		return nil, err
+	}
+	headers, body := splitEML(data)
+
+	var results []*DKIMResult
+	for _, h := range headers {
+		if strings.EqualFold(h.name, "DKIM-Signature") {
+			results = append(results, verifyDKIMSignature(h, headers, body, resolver))
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNoDKIMSignature
+	}
+	return results, nil
+}
+
+// rawHeader is a single header field exactly as it appears in a message, raw includes any folding and the trailing
+// CRLF.
+type rawHeader struct {
+	name string
+	raw  string
+}
+
+// value returns the unparsed header field value, everything after the colon.
+func (h rawHeader) value() string {
+	return h.raw[strings.IndexByte(h.raw, ':')+1:]
+}
+
+// splitEML splits a message into its header fields and body, normalising line endings to CRLF.
+func splitEML(data []byte) ([]rawHeader, []byte) {
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+	data = bytes.Replace(data, []byte("\n"), []byte("\r\n"), -1)
+
+	var headers []rawHeader
+	for len(data) > 0 {
+		if bytes.HasPrefix(data, []byte("\r\n")) {
+			return headers, data[2:]
+		}
+		end := bytes.Index(data, []byte("\r\n"))
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += 2
+		}
+		line := string(data[:end])
+		data = data[end:]
+
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1].raw += line
+			continue
+		}
+		if colon := strings.IndexByte(line, ':'); colon > 0 {
+			headers = append(headers, rawHeader{name: strings.TrimSpace(line[:colon]), raw: line})
+		}
+	}
+	return headers, nil
+}
+
+func verifyDKIMSignature(sig rawHeader, headers []rawHeader, body []byte, resolver DKIMResolver) *DKIMResult {
+	result := &DKIMResult{Result: CheckFail, HeaderCanonicalization: "simple", BodyCanonicalization: "simple"}
+	tags, err := parseDKIMTags(sig.value())
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Domain = tags["d"]
+	result.Selector = tags["s"]
+	result.Algorithm = tags["a"]
+	result.BodyHash = stripWhitespace(tags["bh"])
+	if c, ok := tags["c"]; ok {
+		parts := strings.SplitN(c, "/", 2)
+		result.HeaderCanonicalization = parts[0]
+		if len(parts) == 2 {
+			result.BodyCanonicalization = parts[1]
+		}
+	}
+	for _, name := range strings.Split(tags["h"], ":") {
+		if name = strings.TrimSpace(name); name != "" {
+			result.SignedHeaders = append(result.SignedHeaders, name)
+		}
+	}
+
+	if tags["v"] != "1" {
+		result.Err = fmt.Errorf("mailosaur: unsupported DKIM version %q", tags["v"])
+		return result
+	}
+	for _, required := range []string{"a", "b", "bh", "d", "h", "s"} {
+		if _, ok := tags[required]; !ok {
+			result.Err = fmt.Errorf("mailosaur: DKIM signature is missing required tag %s", required)
+			return result
+		}
+	}
+	if !signsHeader(result.SignedHeaders, "From") {
+		result.Err = errors.New("mailosaur: DKIM signature does not sign the From header")
+		return result
+	}
+	if err := checkDKIMExpiry(tags, time.Now()); err != nil {
+		result.Err = err
+		return result
+	}
+	keyType, hashAlg, err := dkimAlgorithm(result.Algorithm)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	canonHeader, ok := dkimHeaderCanonicalizers[result.HeaderCanonicalization]
+	if !ok {
+		result.Err = fmt.Errorf("mailosaur: unsupported DKIM header canonicalization %q", result.HeaderCanonicalization)
+		return result
+	}
+	canonBody, ok := dkimBodyCanonicalizers[result.BodyCanonicalization]
+	if !ok {
+		result.Err = fmt.Errorf("mailosaur: unsupported DKIM body canonicalization %q", result.BodyCanonicalization)
+		return result
+	}
+
+	canonical := canonBody(body)
+	if l, ok := tags["l"]; ok {
+		length, err := strconv.Atoi(l)
+		if err != nil || length < 0 || length > len(canonical) {
+			result.Err = fmt.Errorf("mailosaur: invalid DKIM body length %q", l)
+			return result
+		}
+		canonical = canonical[:length]
+	}
+	bodyHash := hashAlg.New()
+	bodyHash.Write(canonical)
+	result.ComputedBodyHash = base64.StdEncoding.EncodeToString(bodyHash.Sum(nil))
+	if result.ComputedBodyHash != result.BodyHash {
+		result.Err = ErrDKIMBodyHash
+		return result
+	}
+
+	key, err := lookupDKIMKey(resolver, result.Selector+"._domainkey."+result.Domain, keyType)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	signature, err := base64.StdEncoding.DecodeString(stripWhitespace(tags["b"]))
+	if err != nil {
+		result.Err = fmt.Errorf("mailosaur: invalid DKIM signature encoding: %v", err)
+		return result
+	}
+
+	headerHash := hashAlg.New()
+	for _, h := range selectSignedHeaders(headers, result.SignedHeaders) {
+		io.WriteString(headerHash, canonHeader(h))
+	}
+	unsigned := rawHeader{name: sig.name, raw: sig.raw[:len(sig.raw)-len(sig.value())] + dkimSignatureValue.ReplaceAllString(sig.value(), "${1}")}
+	io.WriteString(headerHash, strings.TrimSuffix(canonHeader(unsigned), "\r\n"))
+
+	if err := verifyDKIMHash(key, hashAlg, headerHash.Sum(nil), signature); err != nil {
+		result.Err = err
+		return result
+	}
+	result.Result = CheckPass
+	return result
+}
+
+// parseDKIMTags parses a DKIM tag list, e.g. "v=1; a=rsa-sha256", as used by signatures and key records.
+func parseDKIMTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		eq := strings.IndexByte(spec, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("mailosaur: malformed DKIM tag %q", strings.TrimSpace(spec))
+		}
+		name := strings.TrimSpace(spec[:eq])
+		if _, ok := tags[name]; ok {
+			return nil, fmt.Errorf("mailosaur: duplicate DKIM tag %q", name)
+		}
+		tags[name] = strings.TrimSpace(spec[eq+1:])
+	}
+	return tags, nil
+}
+
+// signsHeader reports whether the h= tag names the header field name.
+func signsHeader(signed []string, name string) bool {
+	for _, h := range signed {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDKIMExpiry returns ErrDKIMExpired if the signature's x= expiry, in seconds since the epoch, is before now.
+// Signatures without x= never expire.
+func checkDKIMExpiry(tags map[string]string, now time.Time) error {
+	x, ok := tags["x"]
+	if !ok {
+		return nil
+	}
+	expires, err := strconv.ParseInt(x, 10, 64)
+	if err != nil {
+		return fmt.Errorf("mailosaur: invalid DKIM expiry %q", x)
+	}
+	if t, ok := tags["t"]; ok {
+		if signed, err := strconv.ParseInt(t, 10, 64); err == nil && expires < signed {
+			return fmt.Errorf("mailosaur: DKIM expiry %q is before the signature timestamp %q", x, t)
+		}
+	}
+	if now.Unix() > expires {
+		return ErrDKIMExpired
+	}
+	return nil
+}
+
+func stripWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// dkimSignatureValue matches the value of the b= tag, which is excluded when hashing the DKIM-Signature header.
+var dkimSignatureValue = regexp.MustCompile(`((?:^|;)[ \t\r\n]*b[ \t\r\n]*=)[^;]*`)
+
+// dkimAlgorithm returns the key type and hash of a DKIM signing algorithm.
+func dkimAlgorithm(algorithm string) (string, crypto.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "rsa-sha256":
+		return "rsa", crypto.SHA256, nil
+	case "rsa-sha1":
+		return "rsa", crypto.SHA1, nil
+	case "ed25519-sha256":
+		return "ed25519", crypto.SHA256, nil
+	}
+	return "", 0, fmt.Errorf("mailosaur: unsupported DKIM algorithm %q", algorithm)
+}
+
+// selectSignedHeaders returns the header fields named by the h= tag. A name listed several times selects successive
+// instances of the field from the bottom of the header, names with no remaining instance select nothing.
+func selectSignedHeaders(headers []rawHeader, names []string) []rawHeader {
+	used := map[string]int{}
+	var selected []rawHeader
+	for _, name := range names {
+		key := strings.ToLower(name)
+		skip := used[key]
+		for i := len(headers) - 1; i >= 0; i-- {
+			if !strings.EqualFold(headers[i].name, name) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			selected = append(selected, headers[i])
+			break
+		}
+		used[key]++
+	}
+	return selected
+}
+
+var dkimHeaderCanonicalizers = map[string]func(rawHeader) string{
+	"simple": func(h rawHeader) string {
+		return h.raw
+	},
+	"relaxed": func(h rawHeader) string {
+		value := strings.Replace(h.value(), "\r\n", "", -1)
+		return strings.ToLower(h.name) + ":" + strings.TrimSpace(collapseWhitespace(value)) + "\r\n"
+	},
+}
+
+var dkimBodyCanonicalizers = map[string]func([]byte) []byte{
+	"simple": func(body []byte) []byte {
+		return append(bytes.TrimRight(body, "\r\n"), "\r\n"...)
+	},
+	"relaxed": func(body []byte) []byte {
+		lines := strings.Split(string(body), "\r\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(collapseWhitespace(line), " ")
+		}
+		canonical := strings.TrimRight(strings.Join(lines, "\r\n"), "\r\n")
+		if canonical == "" {
+			return nil
+		}
+		return []byte(canonical + "\r\n")
+	},
+}
+
+// collapseWhitespace replaces every run of spaces and tabs with a single space.
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	return b.String()
+}
+
+// lookupDKIMKey resolves and parses the public key published under name, which must be of keyType.
+func lookupDKIMKey(resolver DKIMResolver, name string, keyType string) (crypto.PublicKey, error) {
+	records, err := resolver.LookupTXT(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("mailosaur: no DKIM key record for %s", name)
+	}
+	tags, err := parseDKIMTags(records[0])
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := tags["v"]; ok && v != "DKIM1" {
+		return nil, fmt.Errorf("mailosaur: unsupported DKIM key record version %q", v)
+	}
+	k, ok := tags["k"]
+	if !ok {
+		k = "rsa"
+	}
+	if !strings.EqualFold(k, keyType) {
+		return nil, fmt.Errorf("mailosaur: DKIM key %s is not an %s key", name, keyType)
+	}
+	p := stripWhitespace(tags["p"])
+	if p == "" {
+		return nil, fmt.Errorf("mailosaur: DKIM key %s has been revoked", name)
+	}
+	der, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return nil, fmt.Errorf("mailosaur: invalid DKIM key %s: %v", name, err)
+	}
+
+	if keyType == "ed25519" {
+		if len(der) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("mailosaur: invalid DKIM key %s: ed25519 keys are %d bytes", name, ed25519.PublicKeySize)
+		}
+		return ed25519.PublicKey(der), nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("mailosaur: DKIM key %s is not an rsa key", name)
+	}
+	key, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("mailosaur: invalid DKIM key %s: %v", name, err)
+	}
+	return key, nil
+}
+
+// verifyDKIMHash checks signature is a valid signature of the header hash by key.
+func verifyDKIMHash(key crypto.PublicKey, hashAlg crypto.Hash, hashed []byte, signature []byte) error {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, hashAlg, hashed, signature); err != nil {
+			return ErrDKIMSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, hashed, signature) {
+			return ErrDKIMSignature
+		}
+	}
+	return nil
+}
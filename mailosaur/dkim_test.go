@@ -0,0 +1,199 @@
+package mailosaur_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	dkimMessage = "From:  Joe SixPack \t<joe@example.com>  \r\n" +
+		"To: Suzie <suzie@example.net>\r\n" +
+		"Subject: Is dinner\r\n ready?\r\n" +
+		"\r\n" +
+		"Hi.\r\n" +
+		"\r\n" +
+		"We  lost\tthe game. \r\n" +
+		"\r\n" +
+		"\r\n"
+
+	relaxedHeaders = "from:Joe SixPack <joe@example.com>\r\nsubject:Is dinner ready?\r\n"
+	relaxedBody    = "Hi.\r\n\r\nWe lost the game.\r\n"
+	simpleHeaders  = "From:  Joe SixPack \t<joe@example.com>  \r\nSubject: Is dinner\r\n ready?\r\n"
+	simpleBody     = "Hi.\r\n\r\nWe  lost\tthe game. \r\n"
+)
+
+// SignDKIM prepends a DKIM-Signature header for the from and subject headers to eml. The signature covers
+// canonHeaders and canonBody, the canonical forms of eml's signed headers and body written out by hand, so tests check
+// VerifyDKIM's canonicalization independently of the signer.
+func SignDKIM(t *testing.T, key crypto.Signer, selector string, canon string, canonHeaders string, canonBody string,
+	eml string) string {
+	bodyHash := sha256.Sum256([]byte(canonBody))
+	algorithm := "rsa-sha256"
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		algorithm = "ed25519-sha256"
+	}
+	tags := fmt.Sprintf("v=1; a=%s; c=%s; d=example.com; s=%s; h=from:subject; bh=%s; b=", algorithm, canon,
+		selector, base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	sigHeader := "DKIM-Signature: " + tags
+	if strings.HasPrefix(canon, "relaxed") {
+		sigHeader = "dkim-signature:" + tags
+	}
+	digest := sha256.Sum256([]byte(canonHeaders + sigHeader))
+
+	var opts crypto.SignerOpts = crypto.SHA256
+	if algorithm == "ed25519-sha256" {
+		opts = crypto.Hash(0)
+	}
+	signature, err := key.Sign(rand.Reader, digest[:], opts)
+	require.NoError(t, err)
+	return "DKIM-Signature: " + tags + base64.StdEncoding.EncodeToString(signature) + "\r\n" + eml
+}
+
+func TestVerifyDKIM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := mailosaur.DKIMKeys{
+		"rsa._domainkey.example.com":     "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(rsaPublic),
+		"ed25519._domainkey.example.com": "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(edPublic),
+	}
+
+	t.Run("verifies relaxed rsa signature", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "relaxed/relaxed", relaxedHeaders, relaxedBody, dkimMessage)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+		require.Equal(t, mailosaur.CheckPass, results[0].Result)
+		require.Equal(t, "example.com", results[0].Domain)
+		require.Equal(t, "rsa", results[0].Selector)
+		require.Equal(t, "rsa-sha256", results[0].Algorithm)
+		require.Equal(t, "relaxed", results[0].HeaderCanonicalization)
+		require.Equal(t, "relaxed", results[0].BodyCanonicalization)
+		require.Equal(t, []string{"from", "subject"}, results[0].SignedHeaders)
+		require.Equal(t, results[0].BodyHash, results[0].ComputedBodyHash)
+	})
+
+	t.Run("verifies simple ed25519 signature with bare line feeds", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, edKey, "ed25519", "simple/simple", simpleHeaders, simpleBody, dkimMessage)
+		eml = strings.Replace(eml, "\r\n", "\n", -1)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		require.Equal(t, mailosaur.CheckPass, results[0].Result)
+		require.Equal(t, "simple", results[0].HeaderCanonicalization)
+	})
+
+	t.Run("detects modified body", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "relaxed/relaxed", relaxedHeaders, relaxedBody, dkimMessage)
+		eml = strings.Replace(eml, "lost", "won", 1)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckFail, results[0].Result)
+		require.Equal(t, mailosaur.ErrDKIMBodyHash, results[0].Err)
+		require.NotEqual(t, results[0].BodyHash, results[0].ComputedBodyHash)
+	})
+
+	t.Run("detects modified signed header", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "simple/simple", simpleHeaders, simpleBody, dkimMessage)
+		eml = strings.Replace(eml, "Is dinner", "Was dinner", 1)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckFail, results[0].Result)
+		require.Equal(t, mailosaur.ErrDKIMSignature, results[0].Err)
+	})
+
+	t.Run("ignores changes to unsigned headers", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "simple/simple", simpleHeaders, simpleBody, dkimMessage)
+		eml = strings.Replace(eml, "To: Suzie", "To: Someone Else", 1)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckPass, results[0].Result)
+	})
+
+	t.Run("returns a result for each signature", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "relaxed/simple", relaxedHeaders, simpleBody, dkimMessage)
+		eml = SignDKIM(t, rsaKey, "ed25519", "relaxed/relaxed", relaxedHeaders, relaxedBody, eml)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, mailosaur.CheckFail, results[0].Result)
+		require.Contains(t, results[0].Err.Error(), "is not an rsa key")
+		require.Equal(t, mailosaur.CheckPass, results[1].Result)
+	})
+
+	t.Run("fails when key cannot be resolved", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "unknown", "relaxed/relaxed", relaxedHeaders, relaxedBody, dkimMessage)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckFail, results[0].Result)
+		require.EqualError(t, results[0].Err, "mailosaur: no DKIM key record for unknown._domainkey.example.com")
+	})
+
+	t.Run("fails when from header is not signed", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "relaxed/relaxed", relaxedHeaders, relaxedBody, dkimMessage)
+		eml = strings.Replace(eml, "h=from:subject", "h=subject", 1)
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(eml), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckFail, results[0].Result)
+		require.EqualError(t, results[0].Err, "mailosaur: DKIM signature does not sign the From header")
+	})
+
+	t.Run("fails when signature has expired", func(t *testing.T) {
+		t.Parallel()
+		eml := SignDKIM(t, rsaKey, "rsa", "relaxed/relaxed", relaxedHeaders, relaxedBody, dkimMessage)
+		expiry := func(x int64) string {
+			return strings.Replace(eml, "v=1; ", fmt.Sprintf("v=1; x=%d; ", x), 1)
+		}
+
+		results, err := mailosaur.VerifyDKIM(strings.NewReader(expiry(time.Now().Add(-time.Hour).Unix())), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.CheckFail, results[0].Result)
+		require.Equal(t, mailosaur.ErrDKIMExpired, results[0].Err)
+
+		// an unexpired x= passes the expiry check, the signature then fails as the tag was added after signing
+		results, err = mailosaur.VerifyDKIM(strings.NewReader(expiry(time.Now().Add(time.Hour).Unix())), keys)
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.ErrDKIMSignature, results[0].Err)
+	})
+
+	t.Run("unsigned message", func(t *testing.T) {
+		t.Parallel()
+		_, err := mailosaur.VerifyDKIM(strings.NewReader(dkimMessage), keys)
+		require.True(t, errors.Is(err, mailosaur.ErrNoDKIMSignature))
+	})
+}
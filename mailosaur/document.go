@@ -0,0 +1,133 @@
+package mailosaur
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrNoHTML is returned by Message.Document when the message has no HTML body.
+	ErrNoHTML = errors.New("mailosaur: message has no HTML body")
+	// ErrElementNotFound is returned when no element of an HTMLDocument matches a query.
+	ErrElementNotFound = errors.New("mailosaur: no matching element")
+)
+
+// HTMLDocument is the parsed HTML body of a message, queried with CSS selectors.
+type HTMLDocument struct {
+	root *html.Node
+}
+
+// Document parses the message's HTML body, returning ErrNoHTML if it has none.
+func (m *Message) Document() (*HTMLDocument, error) {
+	if m.HTML == nil {
+		return nil, ErrNoHTML
+	}
+	root, err := html.Parse(strings.NewReader(m.HTML.Body))
+	if err != nil {
+		return nil, err
+	}
+	return &HTMLDocument{root: root}, nil
+}
+
+// Element is a single element of an HTMLDocument.
+type Element struct {
+	node *html.Node
+
+	// Tag is the lower case element name, e.g. "a".
+	Tag string
+	// Text is the text within the element, with runs of whitespace collapsed to a single space.
+	Text       string
+	Attributes map[string]string
+}
+
+func newElement(n *html.Node) *Element {
+	attributes := make(map[string]string, len(n.Attr))
+	for _, attr := range n.Attr {
+		attributes[attr.Key] = attr.Val
+	}
+	return &Element{node: n, Tag: n.Data, Text: htmlText(n), Attributes: attributes}
+}
+
+// OuterHTML renders the element, including its own tags.
+func (e *Element) OuterHTML() string {
+	var b strings.Builder
+	if err := html.Render(&b, e.node); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// Find returns the elements within e matching the CSS selector, in document order.
+func (e *Element) Find(selector string) ([]*Element, error) {
+	return find(e.node, selector)
+}
+
+// Find returns the elements matching the CSS selector, e.g. `a[href*="/verify"]`, in document order.
+func (d *HTMLDocument) Find(selector string) ([]*Element, error) {
+	return find(d.root, selector)
+}
+
+// First returns the first element matching the CSS selector, or ErrElementNotFound if there is none.
+func (d *HTMLDocument) First(selector string) (*Element, error) {
+	elements, err := d.Find(selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrElementNotFound, selector)
+	}
+	return elements[0], nil
+}
+
+func find(root *html.Node, selector string) ([]*Element, error) {
+	compiled, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("mailosaur: invalid selector %q: %v", selector, err)
+	}
+	var elements []*Element
+	for _, n := range compiled.MatchAll(root) {
+		elements = append(elements, newElement(n))
+	}
+	return elements, nil
+}
+
+// buttonSelector matches the elements that act as buttons in an email, which are most often styled links.
+const buttonSelector = `a, button, input[type=submit], input[type=button], [role=button]`
+
+// ButtonByText returns the first link or button whose text, or value for input buttons, is text. Text is compared
+// case insensitively, ignoring surrounding whitespace. Returns ErrElementNotFound if there is none.
+func (d *HTMLDocument) ButtonByText(text string) (*Element, error) {
+	buttons, err := d.Find(buttonSelector)
+	if err != nil {
+		return nil, err
+	}
+	want := strings.Join(strings.Fields(text), " ")
+	for _, button := range buttons {
+		label := button.Text
+		if button.Tag == "input" {
+			label = strings.Join(strings.Fields(button.Attributes["value"]), " ")
+		}
+		if strings.EqualFold(label, want) {
+			return button, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: button %q", ErrElementNotFound, text)
+}
+
+// ImageByAlt returns the first image whose alt text is alt, or ErrElementNotFound if there is none.
+func (d *HTMLDocument) ImageByAlt(alt string) (*Element, error) {
+	images, err := d.Find("img[alt]")
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		if strings.TrimSpace(image.Attributes["alt"]) == strings.TrimSpace(alt) {
+			return image, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: image %q", ErrElementNotFound, alt)
+}
@@ -0,0 +1,94 @@
+package mailosaur_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+const documentHTML = `<html><body>
+<table role="presentation"><tr><td class="hero">
+  <img src="https://example.com/logo.png" alt="Acme logo">
+  <h1>Welcome,   Jane</h1>
+  <a class="cta" href="https://example.com/verify?token=abc123" style="color: #fff">
+    Verify   your email
+  </a>
+</td></tr></table>
+<form><input type="submit" value="Unsubscribe"></form>
+<p class="footer">Sent by <a href="https://example.com">Acme</a></p>
+</body></html>`
+
+func TestHTMLDocument(t *testing.T) {
+	document := func(t *testing.T) *mailosaur.HTMLDocument {
+		t.Parallel()
+		msg := &mailosaur.Message{HTML: &mailosaur.MessageContent{Body: documentHTML}}
+		doc, err := msg.Document()
+		require.NoError(t, err)
+		return doc
+	}
+
+	t.Run("finds elements by selector", func(t *testing.T) {
+		doc := document(t)
+		links, err := doc.Find("a[href]")
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		require.Equal(t, "a", links[0].Tag)
+		require.Equal(t, "Verify your email", links[0].Text)
+		require.Equal(t, "https://example.com/verify?token=abc123", links[0].Attributes["href"])
+		require.Equal(t, "cta", links[0].Attributes["class"])
+	})
+
+	t.Run("finds nested elements", func(t *testing.T) {
+		doc := document(t)
+		footer, err := doc.First("p.footer")
+		require.NoError(t, err)
+		require.Equal(t, "Sent by Acme", footer.Text)
+
+		links, err := footer.Find("a")
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		require.Equal(t, `<a href="https://example.com">Acme</a>`, links[0].OuterHTML())
+	})
+
+	t.Run("finds buttons by text", func(t *testing.T) {
+		doc := document(t)
+		cta, err := doc.ButtonByText("verify your email")
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/verify?token=abc123", cta.Attributes["href"])
+
+		unsubscribe, err := doc.ButtonByText("Unsubscribe")
+		require.NoError(t, err)
+		require.Equal(t, "input", unsubscribe.Tag)
+
+		_, err = doc.ButtonByText("Buy now")
+		require.True(t, errors.Is(err, mailosaur.ErrElementNotFound))
+		require.EqualError(t, err, `mailosaur: no matching element: button "Buy now"`)
+	})
+
+	t.Run("finds images by alt text", func(t *testing.T) {
+		doc := document(t)
+		logo, err := doc.ImageByAlt("Acme logo")
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/logo.png", logo.Attributes["src"])
+
+		_, err = doc.ImageByAlt("Banner")
+		require.True(t, errors.Is(err, mailosaur.ErrElementNotFound))
+	})
+
+	t.Run("reports invalid selectors and missing elements", func(t *testing.T) {
+		doc := document(t)
+		_, err := doc.Find("a[")
+		require.Error(t, err)
+
+		_, err = doc.First("h2")
+		require.True(t, errors.Is(err, mailosaur.ErrElementNotFound))
+	})
+
+	t.Run("message without html", func(t *testing.T) {
+		t.Parallel()
+		_, err := (&mailosaur.Message{}).Document()
+		require.Equal(t, mailosaur.ErrNoHTML, err)
+	})
+}
@@ -0,0 +1,448 @@
+package mailosaur
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ParseEML builds a Message from a raw RFC 5322 message, such as an .eml file or one returned by DownloadEML, filling
+// in its addresses, subject, headers, bodies and attachments the way mailosaur does. Bodies are decoded from their
+// transfer encoding and converted to UTF-8. Attachments have their Content set but no Id, and addresses that cannot be
+// parsed are left out. Received is the time the message reached its last hop, or its Date if it has no Received
+// headers.
+func ParseEML(r io.Reader) (*Message, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw, body := splitEML(data)
+	if len(raw) == 0 {
+		return nil, errors.New("mailosaur: EML has no headers")
+	}
+
+	msg := &Message{}
+	header := textproto.MIMEHeader{}
+	for _, h := range raw {
+		value := strings.TrimSpace(strings.Replace(h.value(), "\r\n", "", -1))
+		msg.Metadata.Headers = append(msg.Metadata.Headers, Header{Field: h.name, Value: value})
+		header.Add(h.name, value)
+	}
+
+	headers := msg.Metadata.Headers
+	msg.From = parseAddresses(header.Get("From"))
+	msg.To = parseAddresses(header.Get("To"))
+	msg.CC = parseAddresses(header.Get("Cc"))
+	msg.BCC = parseAddresses(header.Get("Bcc"))
+	msg.Subject = headers.Get("Subject")
+	if hops := headers.Received(); len(hops) > 0 && !hops[len(hops)-1].Time.IsZero() {
+		msg.Received = hops[len(hops)-1].Time
+	} else if date, err := headers.Date(); err == nil {
+		msg.Received = date
+	}
+
+	if err := parseEntity(msg, header, body); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// addressParser parses address lists, decoding encoded words in display names.
+var addressParser = &mail.AddressParser{WordDecoder: headerDecoder}
+
+// parseAddresses parses an address list into the name and email maps used by Message.
+func parseAddresses(value string) []map[string]string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	list, err := addressParser.ParseList(value)
+	if err != nil {
+		return nil
+	}
+	addresses := make([]map[string]string, 0, len(list))
+	for _, address := range list {
+		addresses = append(addresses, map[string]string{"name": address.Name, "email": address.Address})
+	}
+	return addresses
+}
+
+// parseEntity adds a MIME entity to msg. Multipart entities are walked depth first, the first text/plain and
+// text/html entities not marked as attachments become the message's bodies and every other entity an attachment.
+func parseEntity(msg *Message, header textproto.MIMEHeader, body []byte) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045 default for entities with a missing or malformed Content-Type
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("mailosaur: %s entity has no boundary", mediaType)
+		}
+		reader := multipart.NewReader(bytes.NewReader(body), boundary)
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("mailosaur: reading %s entity: %v", mediaType, err)
+			}
+			content, err := ioutil.ReadAll(part)
+			if err != nil {
+				return fmt.Errorf("mailosaur: reading %s entity: %v", mediaType, err)
+			}
+			if err := parseEntity(msg, part.Header, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return err
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	if disposition != "attachment" {
+		switch {
+		case mediaType == "text/html" && msg.HTML == nil:
+			msg.HTML = parseHTMLContent(toUTF8(content, params["charset"]))
+			return nil
+		case mediaType == "text/plain" && msg.Text == nil:
+			msg.Text = parseTextContent(toUTF8(content, params["charset"]))
+			return nil
+		}
+	}
+
+	fileName := dispositionParams["filename"]
+	if fileName == "" {
+		fileName = params["name"]
+	}
+	msg.Attachments = append(msg.Attachments, &Attachment{
+		ContentType: mediaType,
+		FileName:    decodeHeader(fileName),
+		ContentId:   strings.Trim(header.Get("Content-Id"), "<> "),
+		Length:      int64(len(content)),
+		Content:     content,
+	})
+	return nil
+}
+
+// decodeTransferEncoding decodes the body of a single part MIME entity.
+func decodeTransferEncoding(encoding string, body []byte) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(stripWhitespace(string(body)))
+		if err != nil {
+			return nil, fmt.Errorf("mailosaur: invalid base64 entity: %v", err)
+		}
+		return decoded, nil
+	case "quoted-printable":
+		decoded, err := ioutil.ReadAll(quotedprintable.NewReader(bytes.NewReader(body)))
+		if err != nil {
+			return nil, fmt.Errorf("mailosaur: invalid quoted-printable entity: %v", err)
+		}
+		return decoded, nil
+	}
+	return body, nil
+}
+
+// charsetReader converts text in any charset known to the WHATWG encoding standard to UTF-8.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	return charset.NewReaderLabel(label, input)
+}
+
+// toUTF8 converts content from the named charset to UTF-8, content in an unknown charset is returned unchanged.
+func toUTF8(content []byte, label string) string {
+	if label == "" || strings.EqualFold(label, "utf-8") || strings.EqualFold(label, "us-ascii") {
+		return string(content)
+	}
+	reader, err := charsetReader(label, bytes.NewReader(content))
+	if err != nil {
+		return string(content)
+	}
+	converted, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return string(content)
+	}
+	return string(converted)
+}
+
+// parseHTMLContent extracts the links and images from an HTML body.
+func parseHTMLContent(body string) *MessageContent {
+	content := &MessageContent{Body: body, Links: []*Link{}, Images: []*Image{}}
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return content
+	}
+
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "a":
+				if href, ok := htmlAttr(n, "href"); ok {
+					content.Links = append(content.Links, &Link{Href: href, Text: htmlText(n)})
+				}
+			case "img":
+				src, _ := htmlAttr(n, "src")
+				alt, _ := htmlAttr(n, "alt")
+				content.Images = append(content.Images, &Image{Src: src, Alt: alt})
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(doc)
+	return content
+}
+
+// htmlAttr returns the value of the named attribute of n.
+func htmlAttr(n *html.Node, name string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// htmlText returns the text within n, with runs of whitespace collapsed to a single space.
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// textLink matches a URL in a plain text body.
+var textLink = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// parseTextContent extracts the links from a plain text body, each link's text is its URL.
+func parseTextContent(body string) *MessageContent {
+	content := &MessageContent{Body: body, Links: []*Link{}}
+	for _, href := range textLink.FindAllString(body, -1) {
+		href = strings.TrimRight(href, ".,;:!?)]'")
+		content.Links = append(content.Links, &Link{Href: href, Text: href})
+	}
+	return content
+}
+
+// mimeEntity is a single MIME entity, its body already encoded for transfer.
+type mimeEntity struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// generatedHeaders are the header fields WriteEML writes itself rather than copying from the message's Metadata.
+var generatedHeaders = map[string]bool{
+	"from": true, "to": true, "cc": true, "bcc": true, "subject": true, "date": true,
+	"mime-version": true, "content-type": true, "content-transfer-encoding": true,
+}
+
+// WriteEML writes the message as RFC 5322 source, readable by ParseEML. The text and HTML bodies are written as a
+// multipart/alternative entity, followed by any attachments whose Content is set, such as those of a message built by
+// ParseEML. Header fields from Metadata are copied, except for the address, subject, date and MIME fields which are
+// rebuilt from the message. Bcc recipients are left out, as they would be from a delivered message.
+func (m *Message) WriteEML(w io.Writer) error {
+	entity, err := m.mimeBody()
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	writeAddressHeader(&b, "From", m.From)
+	writeAddressHeader(&b, "To", m.To)
+	writeAddressHeader(&b, "Cc", m.CC)
+	if m.Subject != "" {
+		fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	}
+	if date := m.Metadata.Headers.Get("Date"); date != "" {
+		fmt.Fprintf(&b, "Date: %s\r\n", date)
+	} else if !m.Received.IsZero() {
+		fmt.Fprintf(&b, "Date: %s\r\n", m.Received.Format(time.RFC1123Z))
+	}
+	for _, h := range m.Metadata.Headers {
+		if !generatedHeaders[strings.ToLower(h.Field)] {
+			fmt.Fprintf(&b, "%s: %s\r\n", h.Field, h.Value)
+		}
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	writeMIMEHeader(&b, entity.header)
+	b.WriteString("\r\n")
+	b.Write(entity.body)
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// ToNetMail returns the message as a *mail.Message, as written by WriteEML.
+func (m *Message) ToNetMail() (*mail.Message, error) {
+	var b bytes.Buffer
+	if err := m.WriteEML(&b); err != nil {
+		return nil, err
+	}
+	return mail.ReadMessage(&b)
+}
+
+func writeAddressHeader(b *bytes.Buffer, field string, addresses []map[string]string) {
+	if len(addresses) == 0 {
+		return
+	}
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		formatted = append(formatted, (&mail.Address{Name: address["name"], Address: address["email"]}).String())
+	}
+	fmt.Fprintf(b, "%s: %s\r\n", field, strings.Join(formatted, ",\r\n "))
+}
+
+// writeMIMEHeader writes the fields of header sorted by name, so the output is deterministic.
+func writeMIMEHeader(b *bytes.Buffer, header textproto.MIMEHeader) {
+	fields := make([]string, 0, len(header))
+	for field := range header {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		for _, value := range header[field] {
+			fmt.Fprintf(b, "%s: %s\r\n", field, value)
+		}
+	}
+}
+
+// mimeBody builds the MIME entity holding the message's bodies and downloaded attachments.
+func (m *Message) mimeBody() (*mimeEntity, error) {
+	var bodies []*mimeEntity
+	if m.Text != nil {
+		bodies = append(bodies, textEntity("text/plain", m.Text.Body))
+	}
+	if m.HTML != nil {
+		bodies = append(bodies, textEntity("text/html", m.HTML.Body))
+	}
+
+	var body *mimeEntity
+	switch len(bodies) {
+	case 0:
+		body = textEntity("text/plain", "")
+	case 1:
+		body = bodies[0]
+	default:
+		var err error
+		if body, err = multipartEntity("alternative", bodies); err != nil {
+			return nil, err
+		}
+	}
+
+	parts := []*mimeEntity{body}
+	for _, attachment := range m.Attachments {
+		if attachment.Content != nil {
+			parts = append(parts, attachmentEntity(attachment))
+		}
+	}
+	if len(parts) == 1 {
+		return body, nil
+	}
+	return multipartEntity("mixed", parts)
+}
+
+// textEntity encodes text as a UTF-8 entity. Text is quoted-printable encoded, unless it has line breaks other than
+// CRLF which quoted-printable would not preserve.
+func textEntity(mediaType string, text string) *mimeEntity {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, map[string]string{"charset": "utf-8"}))
+	if strings.ContainsAny(strings.Replace(text, "\r\n", "", -1), "\r\n") {
+		header.Set("Content-Transfer-Encoding", "base64")
+		return &mimeEntity{header: header, body: encodeBase64([]byte(text))}
+	}
+
+	var b bytes.Buffer
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(text))
+	qp.Close()
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return &mimeEntity{header: header, body: b.Bytes()}
+}
+
+// base64LineLength is the longest line RFC 2045 allows in base64 encoded entities.
+const base64LineLength = 76
+
+// encodeBase64 encodes content as base64 split into lines.
+func encodeBase64(content []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	var b bytes.Buffer
+	for len(encoded) > base64LineLength {
+		b.WriteString(encoded[:base64LineLength] + "\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	b.WriteString(encoded)
+	return b.Bytes()
+}
+
+// attachmentEntity encodes an attachment as a base64 entity, inline if it has a content id.
+func attachmentEntity(attachment *Attachment) *mimeEntity {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := "attachment"
+	header := textproto.MIMEHeader{}
+	if attachment.ContentId != "" {
+		disposition = "inline"
+		header.Set("Content-Id", "<"+attachment.ContentId+">")
+	}
+	var params map[string]string
+	if attachment.FileName != "" {
+		params = map[string]string{"filename": attachment.FileName}
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	header.Set("Content-Transfer-Encoding", "base64")
+	return &mimeEntity{header: header, body: encodeBase64(attachment.Content)}
+}
+
+// multipartEntity combines parts into a multipart entity of the given subtype, e.g. "mixed".
+func multipartEntity(subtype string, parts []*mimeEntity) (*mimeEntity, error) {
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(part.header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := partWriter.Write(part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": writer.Boundary()}))
+	return &mimeEntity{header: header, body: b.Bytes()}, nil
+}
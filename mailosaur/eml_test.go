@@ -0,0 +1,162 @@
+package mailosaur_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEML(t *testing.T) {
+	parse := func(t *testing.T, name string) *mailosaur.Message {
+		t.Parallel()
+		msg, err := mailosaur.ParseEML(bytes.NewReader(LoadTestData(t, "eml/"+name)))
+		require.NoError(t, err)
+		return msg
+	}
+
+	t.Run("multipart alternative", func(t *testing.T) {
+		msg := parse(t, "alternative.eml")
+
+		require.Equal(t, "Welcome to Acme ☕", msg.Subject)
+		require.Equal(t, []map[string]string{{"name": "Acme Café", "email": "noreply@example.com"}}, msg.From)
+		require.Equal(t, []map[string]string{
+			{"name": "Doe, Jane", "email": "jane.abc123@mailosaur.io"},
+			{"name": "", "email": "bob.abc123@mailosaur.io"},
+		}, msg.To)
+		require.Equal(t, []map[string]string{{"name": "Support", "email": "support@example.com"}}, msg.CC)
+		require.Empty(t, msg.BCC)
+		require.True(t, msg.Received.Equal(time.Date(2020, 6, 2, 10, 0, 5, 0, time.UTC)), msg.Received)
+		require.Equal(t, "welcome.1@example.com", msg.Metadata.Headers.MessageID())
+
+		require.Equal(t, "Welcome to Acme Café! Verify your account at https://example.com/verify?token=abc123.\r\n\r\n"+
+			"See you soon.", msg.Text.Body)
+		require.Equal(t, []*mailosaur.Link{{Href: "https://example.com/verify?token=abc123",
+			Text: "https://example.com/verify?token=abc123"}}, msg.Text.Links)
+
+		require.Contains(t, msg.HTML.Body, "<p>Welcome to Acme Café!</p>")
+		require.Equal(t, []*mailosaur.Link{{Href: "https://example.com/verify?token=abc123", Text: "Verify account"}},
+			msg.HTML.Links)
+		require.Equal(t, []*mailosaur.Image{{Src: "https://example.com/logo.png", Alt: "Acme logo"}}, msg.HTML.Images)
+		require.Empty(t, msg.Attachments)
+	})
+
+	t.Run("nested mixed and related parts with bare line feeds", func(t *testing.T) {
+		msg := parse(t, "mixed_related.eml")
+
+		require.True(t, msg.Received.Equal(time.Date(2020, 6, 3, 9, 30, 0, 0, time.UTC)), msg.Received)
+		require.Equal(t, "Your invoice is attached.", msg.Text.Body)
+		require.Equal(t, []*mailosaur.Image{{Src: "cid:logo@example.com"}}, msg.HTML.Images)
+
+		require.Len(t, msg.Attachments, 3)
+		require.Equal(t, &mailosaur.Attachment{
+			ContentType: "image/png",
+			ContentId:   "logo@example.com",
+			Length:      8,
+			Content:     []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'},
+		}, msg.Attachments[0])
+		require.Equal(t, "application/pdf", msg.Attachments[1].ContentType)
+		require.Equal(t, "Rechnung März.pdf", msg.Attachments[1].FileName)
+		require.Equal(t, "%PDF-1.4 fake", string(msg.Attachments[1].Content))
+		require.Equal(t, "notes.txt", msg.Attachments[2].FileName)
+		require.Equal(t, "attached notes", string(msg.Attachments[2].Content))
+	})
+
+	t.Run("latin-1 quoted printable", func(t *testing.T) {
+		msg := parse(t, "latin1.eml")
+
+		require.Equal(t, "Résumé", msg.Subject)
+		require.Equal(t, "René", msg.From[0]["name"])
+		require.Equal(t, "Voilà mon résumé.\r\n", msg.Text.Body)
+		require.Nil(t, msg.HTML)
+	})
+
+	t.Run("windows-1252 html", func(t *testing.T) {
+		msg := parse(t, "windows1252.eml")
+
+		require.Equal(t, "“Sale”", msg.Subject)
+		require.Equal(t, "<p>“Everything” from €5</p>\r\n", msg.HTML.Body)
+		require.Nil(t, msg.Text)
+	})
+
+	t.Run("rejects malformed messages", func(t *testing.T) {
+		t.Parallel()
+		_, err := mailosaur.ParseEML(strings.NewReader("\r\nno headers"))
+		require.Error(t, err)
+
+		_, err = mailosaur.ParseEML(strings.NewReader("Content-Type: multipart/mixed\r\n\r\nbody"))
+		require.EqualError(t, err, "mailosaur: multipart/mixed entity has no boundary")
+	})
+}
+
+func TestWriteEML(t *testing.T) {
+	roundTrip := func(t *testing.T, msg *mailosaur.Message) *mailosaur.Message {
+		var b bytes.Buffer
+		require.NoError(t, msg.WriteEML(&b))
+		parsed, err := mailosaur.ParseEML(&b)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	for _, name := range []string{"alternative.eml", "mixed_related.eml", "latin1.eml", "windows1252.eml"} {
+		name := name
+		t.Run("round trips "+name, func(t *testing.T) {
+			t.Parallel()
+			msg, err := mailosaur.ParseEML(bytes.NewReader(LoadTestData(t, "eml/"+name)))
+			require.NoError(t, err)
+
+			parsed := roundTrip(t, msg)
+			require.Equal(t, msg.Subject, parsed.Subject)
+			require.Equal(t, msg.From, parsed.From)
+			require.Equal(t, msg.To, parsed.To)
+			require.Equal(t, msg.CC, parsed.CC)
+			require.Equal(t, msg.Text, parsed.Text)
+			require.Equal(t, msg.HTML, parsed.HTML)
+			require.Equal(t, msg.Attachments, parsed.Attachments)
+			require.Equal(t, msg.Metadata.Headers.MessageID(), parsed.Metadata.Headers.MessageID())
+			require.True(t, msg.Received.Equal(parsed.Received), parsed.Received)
+		})
+	}
+
+	t.Run("writes message from API", func(t *testing.T) {
+		t.Parallel()
+		var msg mailosaur.Message
+		require.NoError(t, json.Unmarshal(LoadTestData(t, "get_message_success.json"), &msg))
+
+		parsed := roundTrip(t, &msg)
+		require.Equal(t, "Email subject line", parsed.Subject)
+		require.Equal(t, msg.To, parsed.To)
+		require.Equal(t, msg.Text.Body, parsed.Text.Body)
+		require.Equal(t, msg.HTML.Body, parsed.HTML.Body)
+		require.WithinDuration(t, msg.Received, parsed.Received, time.Second)
+	})
+
+	t.Run("converts to net/mail", func(t *testing.T) {
+		t.Parallel()
+		msg := &mailosaur.Message{}
+		msg.Subject = "Hello ☕"
+		msg.From = []map[string]string{{"name": "Acme", "email": "noreply@example.com"}}
+		msg.BCC = []map[string]string{{"email": "hidden@example.com"}}
+		msg.Text = &mailosaur.MessageContent{Body: "Hi there"}
+		msg.Metadata.Headers = mailosaur.Headers{{Field: "X-Campaign", Value: "spring"}}
+
+		netMsg, err := msg.ToNetMail()
+		require.NoError(t, err)
+		subject, err := new(mime.WordDecoder).DecodeHeader(netMsg.Header.Get("Subject"))
+		require.NoError(t, err)
+		require.Equal(t, "Hello ☕", subject)
+		require.Equal(t, `"Acme" <noreply@example.com>`, netMsg.Header.Get("From"))
+		require.Equal(t, "spring", netMsg.Header.Get("X-Campaign"))
+		require.Empty(t, netMsg.Header.Get("Bcc"))
+		body, err := ioutil.ReadAll(quotedprintable.NewReader(netMsg.Body))
+		require.NoError(t, err)
+		require.Equal(t, "Hi there", string(body))
+	})
+}
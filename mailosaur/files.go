@@ -0,0 +1,40 @@
+package mailosaur
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+)
+
+// DownloadAttachment downloads the content of a single attachment, identified by the id listed on its Message.
+func (c *Client) DownloadAttachment(ctx context.Context, attachmentID string) ([]byte, error) {
+	if c.cache != nil {
+		if content, ok := c.cache.get(attachmentCacheKey + attachmentID); ok {
+			return append([]byte(nil), content...), nil
+		}
+	}
+
+	httpResp, err := c.do(ctx, http.MethodGet, "files/attachments/"+attachmentID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	content, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		c.cache.set(attachmentCacheKey+attachmentID, append([]byte(nil), content...))
+	}
+	return content, nil
+}
+
+// DownloadEML downloads the raw RFC 5322 source of a message, exactly as mailosaur received it, e.g. for VerifyDKIM.
+func (c *Client) DownloadEML(ctx context.Context, messageID string) ([]byte, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "files/email/"+messageID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	return ioutil.ReadAll(httpResp.Body)
+}
@@ -0,0 +1,59 @@
+package mailosaur_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadAttachment(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("calls attachment download endpoint", func(t *testing.T) {
+		ts := setup(t, &TestResponse{Body: []byte("content"), StatusCode: http.StatusOK})
+
+		attachmentID := RandomMessageID()
+		_, err := ts.client.DownloadAttachment(context.Background(), attachmentID)
+		require.NoError(t, err)
+		require.Equal(t, "/files/attachments/"+attachmentID, ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodGet, ts.recvReq.Method)
+	})
+
+	t.Run("returns attachment content", func(t *testing.T) {
+		ts := setup(t, &TestResponse{Body: []byte("content"), StatusCode: http.StatusOK})
+
+		content, err := ts.client.DownloadAttachment(context.Background(), RandomMessageID())
+		require.NoError(t, err)
+		require.Equal(t, "content", string(content))
+	})
+}
+
+func TestDownloadEML(t *testing.T) {
+	t.Run("downloads raw message", func(t *testing.T) {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, &TestResponse{Body: []byte("Subject: Hi\r\n\r\nHello"), StatusCode: http.StatusOK})
+		client := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		msgID := RandomMessageID()
+		eml, err := client.DownloadEML(context.Background(), msgID)
+		require.NoError(t, err)
+		require.Equal(t, "Subject: Hi\r\n\r\nHello", string(eml))
+		require.Equal(t, "/files/email/"+msgID, recvReq.URL.Path)
+		require.Equal(t, http.MethodGet, recvReq.Method)
+	})
+}
@@ -0,0 +1,199 @@
+package mailosaur
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Metadata holds the technical details of a received message.
+type Metadata struct {
+	Headers Headers `json:"headers"`
+	// Raw is the metadata exactly as the API returned it, including any fields not decoded above.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the metadata fields and keeps a copy of data in Raw.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	type metadata Metadata
+	if err := json.Unmarshal(data, (*metadata)(m)); err != nil {
+		return err
+	}
+	m.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Header is a single header field of a received message, in the order it appeared.
+type Header struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// Headers are the header fields of a received message. Field names are matched case insensitively and values are
+// returned with RFC 2047 encoded words decoded, use the Header values directly for the raw text.
+type Headers []Header
+
+// headerDecoder decodes RFC 2047 encoded words, e.g. "=?UTF-8?Q?caf=C3=A9?=", in any charset.
+var headerDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// decodeHeader decodes the encoded words in value, returning value unchanged if it cannot be decoded.
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// Get returns the decoded value of the first field called name, or an empty string if there is none.
+func (h Headers) Get(name string) string {
+	for _, header := range h {
+		if strings.EqualFold(header.Field, name) {
+			return decodeHeader(header.Value)
+		}
+	}
+	return ""
+}
+
+// Values returns the decoded values of every field called name, in the order they appear.
+func (h Headers) Values(name string) []string {
+	var values []string
+	for _, header := range h {
+		if strings.EqualFold(header.Field, name) {
+			values = append(values, decodeHeader(header.Value))
+		}
+	}
+	return values
+}
+
+// Has reports whether there is a field called name.
+func (h Headers) Has(name string) bool {
+	for _, header := range h {
+		if strings.EqualFold(header.Field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Date parses the Date field.
+func (h Headers) Date() (time.Time, error) {
+	if !h.Has("Date") {
+		return time.Time{}, errors.New("mailosaur: message has no Date header")
+	}
+	return mail.ParseDate(stripComments(h.Get("Date")))
+}
+
+// msgID matches a single message id, e.g. "<1234@example.com>".
+var msgID = regexp.MustCompile(`<([^<>\s]+)>`)
+
+// parseMsgIDs returns the message ids in value without their angle brackets.
+func parseMsgIDs(value string) []string {
+	var ids []string
+	for _, match := range msgID.FindAllStringSubmatch(value, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+// MessageID returns the id in the Message-ID field without its angle brackets, or an empty string if there is none.
+func (h Headers) MessageID() string {
+	ids := parseMsgIDs(h.Get("Message-ID"))
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+// InReplyTo returns the ids of the messages this message replies to, from the In-Reply-To field.
+func (h Headers) InReplyTo() []string {
+	return parseMsgIDs(h.Get("In-Reply-To"))
+}
+
+// References returns the ids of the messages in this message's thread, oldest first, from the References field.
+func (h Headers) References() []string {
+	return parseMsgIDs(h.Get("References"))
+}
+
+// ReceivedHop is a single server that relayed a message, parsed from a Received field.
+type ReceivedHop struct {
+	// From is the server the message was received from, and By the server that received it.
+	From string
+	By   string
+	// With is the protocol the message was received with, e.g. "ESMTPS".
+	With string
+	ID   string
+	For  string
+	// Time is when the message was received, it is zero if the timestamp could not be parsed.
+	Time time.Time
+	// Raw is the unparsed Received field.
+	Raw string
+}
+
+// Received returns the servers that relayed the message, in the order the message passed through them, so the first
+// hop is nearest the sender.
+func (h Headers) Received() []*ReceivedHop {
+	values := h.Values("Received")
+	hops := make([]*ReceivedHop, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		hops = append(hops, parseReceived(values[i]))
+	}
+	return hops
+}
+
+// parseReceived parses a Received field, e.g. "from a.example.com by b.example.com with ESMTP id 123; <date>".
+func parseReceived(value string) *ReceivedHop {
+	hop := &ReceivedHop{Raw: value}
+	clauses := value
+	if semicolon := strings.LastIndexByte(value, ';'); semicolon >= 0 {
+		clauses = value[:semicolon]
+		if t, err := mail.ParseDate(stripComments(value[semicolon+1:])); err == nil {
+			hop.Time = t
+		}
+	}
+
+	fields := strings.Fields(stripComments(clauses))
+	for i := 0; i+1 < len(fields); i++ {
+		var target *string
+		switch strings.ToLower(fields[i]) {
+		case "from":
+			target = &hop.From
+		case "by":
+			target = &hop.By
+		case "with":
+			target = &hop.With
+		case "id":
+			target = &hop.ID
+		case "for":
+			target = &hop.For
+		default:
+			continue
+		}
+		if *target == "" {
+			*target = strings.Trim(fields[i+1], "<>")
+			i++
+		}
+	}
+	return hop
+}
+
+// stripComments removes parenthesised comments, which may be nested, from a header value.
+func stripComments(value string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range value {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
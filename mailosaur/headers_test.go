@@ -0,0 +1,86 @@
+package mailosaur_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaders(t *testing.T) {
+	headers := mailosaur.Headers{
+		{Field: "Received", Value: "from mx.example.net (mx.example.net [192.0.2.2]) by inbound.mailosaur.io " +
+			"with ESMTPS id 9f2c for <jane.abc123@mailosaur.io>; Tue, 2 Jun 2020 10:00:05 +0000"},
+		{Field: "Received", Value: "from app.example.com by mx.example.net (Postfix) with ESMTP id 41ab;\r\n" +
+			" Tue, 2 Jun 2020 10:00:01 +0000 (UTC)"},
+		{Field: "Date", Value: "Tue, 2 Jun 2020 11:00:00 +0100"},
+		{Field: "Subject", Value: "=?UTF-8?Q?Caf=C3=A9_menu?="},
+		{Field: "Message-ID", Value: "<reply.42@example.com>"},
+		{Field: "In-Reply-To", Value: "<original.41@example.com>"},
+		{Field: "References", Value: "<root.1@example.com>\r\n <original.41@example.com>"},
+		{Field: "X-Campaign", Value: "spring"},
+		{Field: "x-campaign", Value: "=?ISO-8859-1?B?c29sZGU=?="},
+	}
+
+	t.Run("gets fields case insensitively", func(t *testing.T) {
+		t.Parallel()
+		require.True(t, headers.Has("x-CAMPAIGN"))
+		require.False(t, headers.Has("X-Missing"))
+		require.Equal(t, "spring", headers.Get("X-Campaign"))
+		require.Equal(t, "", headers.Get("X-Missing"))
+		require.Equal(t, []string{"spring", "solde"}, headers.Values("X-CAMPAIGN"))
+	})
+
+	t.Run("decodes encoded words", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "Café menu", headers.Get("Subject"))
+	})
+
+	t.Run("parses date", func(t *testing.T) {
+		t.Parallel()
+		date, err := headers.Date()
+		require.NoError(t, err)
+		require.True(t, date.Equal(time.Date(2020, 6, 2, 10, 0, 0, 0, time.UTC)), date)
+
+		_, err = mailosaur.Headers{}.Date()
+		require.Error(t, err)
+	})
+
+	t.Run("parses message ids", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "reply.42@example.com", headers.MessageID())
+		require.Equal(t, []string{"original.41@example.com"}, headers.InReplyTo())
+		require.Equal(t, []string{"root.1@example.com", "original.41@example.com"}, headers.References())
+		require.Empty(t, mailosaur.Headers{}.References())
+	})
+
+	t.Run("parses received chain oldest first", func(t *testing.T) {
+		t.Parallel()
+		hops := headers.Received()
+		require.Len(t, hops, 2)
+
+		require.Equal(t, "app.example.com", hops[0].From)
+		require.Equal(t, "mx.example.net", hops[0].By)
+		require.Equal(t, "ESMTP", hops[0].With)
+		require.Equal(t, "41ab", hops[0].ID)
+		require.True(t, hops[0].Time.Equal(time.Date(2020, 6, 2, 10, 0, 1, 0, time.UTC)), hops[0].Time)
+
+		require.Equal(t, "mx.example.net", hops[1].From)
+		require.Equal(t, "inbound.mailosaur.io", hops[1].By)
+		require.Equal(t, "jane.abc123@mailosaur.io", hops[1].For)
+		require.Equal(t, 4*time.Second, hops[1].Time.Sub(hops[0].Time))
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("keeps raw metadata", func(t *testing.T) {
+		t.Parallel()
+		data := `{"headers": [{"field": "Subject", "value": "Hi"}], "ehlo": "mx.example.net"}`
+		var metadata mailosaur.Metadata
+		require.NoError(t, json.Unmarshal([]byte(data), &metadata))
+		require.Equal(t, "Hi", metadata.Headers.Get("Subject"))
+		require.JSONEq(t, data, string(metadata.Raw))
+	})
+}
@@ -0,0 +1,183 @@
+package mailosaur
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// defaultPollInterval is how often operations that wait on the mailosaur API check for changes.
+const defaultPollInterval = time.Second
+
+// ErrInboxEmpty is returned by Inbox.Latest when no message has been sent to the inbox.
+var ErrInboxEmpty = errors.New("mailosaur: inbox has no messages")
+
+// SetPollInterval sets how often operations that wait on the mailosaur API, such as Inbox.Wait and DownloadPreview,
+// check for changes. Defaults to one second, which is also used for intervals of zero or less.
+func SetPollInterval(interval time.Duration) clientOption {
+	return func(c *Client) {
+		if interval <= 0 {
+			interval = defaultPollInterval
+		}
+		c.pollInterval = interval
+	}
+}
+
+// MessageFilter selects messages, it is used by Inbox.Wait to choose which message to wait for.
+type MessageFilter func(*MessageSummary) bool
+
+// SubjectContains selects messages whose subject contains substr.
+func SubjectContains(substr string) MessageFilter {
+	return func(m *MessageSummary) bool {
+		return strings.Contains(m.Subject, substr)
+	}
+}
+
+// SentFrom selects messages sent from the email address.
+func SentFrom(email string) MessageFilter {
+	return func(m *MessageSummary) bool {
+		for _, from := range m.From {
+			if strings.EqualFold(from["email"], email) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Inbox is a unique email address on the client's mailosaur server. Every Inbox operation is scoped to messages sent
+// to its address, so parallel tests sharing a server each see only their own mail.
+type Inbox struct {
+	client  *Client
+	address string
+}
+
+// inboxOption configures a new Inbox
+type inboxOption func(*inboxOptions)
+
+type inboxOptions struct {
+	name string
+}
+
+// SetInboxName includes a readable name in the inbox's generated address, making it easy to find in the mailosaur
+// dashboard. The name is lower cased and any character other than a letter or digit is replaced with a hyphen.
+func SetInboxName(name string) inboxOption {
+	return func(o *inboxOptions) {
+		o.name = name
+	}
+}
+
+// NewInbox returns an Inbox bound to a newly generated email address.
+func (c *Client) NewInbox(options ...inboxOption) *Inbox {
+	var o inboxOptions
+	for _, opt := range options {
+		opt(&o)
+	}
+	prefix := sanitizeLocalPart(o.name)
+	if prefix != "" {
+		prefix += "-"
+	}
+	return &Inbox{
+		client:  c,
+		address: c.generateEmail(prefix),
+	}
+}
+
+// maxInboxNameLen limits the length of inbox names so generated addresses stay well within local part limits.
+const maxInboxNameLen = 40
+
+// sanitizeLocalPart makes name safe for use in the local part of an email address.
+func sanitizeLocalPart(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+		if b.Len() >= maxInboxNameLen {
+			break
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// Address returns the email address messages should be sent to for them to appear in the inbox.
+func (i *Inbox) Address() string {
+	return i.address
+}
+
+func (i *Inbox) lookup() *SearchMessagesLookup {
+	return &SearchMessagesLookup{SentTo: i.address}
+}
+
+// All returns summaries of every message sent to the inbox, newest first.
+func (i *Inbox) All(ctx context.Context) ([]*MessageSummary, error) {
+	return i.client.searchAll(ctx, i.lookup())
+}
+
+// Count returns the number of messages sent to the inbox.
+func (i *Inbox) Count(ctx context.Context) (int, error) {
+	summaries, err := i.All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(summaries), nil
+}
+
+// Latest returns the most recent message sent to the inbox, or ErrInboxEmpty if there is none.
+func (i *Inbox) Latest(ctx context.Context) (*Message, error) {
+	summaries, err := i.client.searchMessages(ctx, i.lookup(), SetItemsPerPage(1))
+	if err != nil {
+		return nil, err
+	}
+	if len(summaries) == 0 {
+		return nil, ErrInboxEmpty
+	}
+	return i.client.getMessage(ctx, summaries[0].Id)
+}
+
+// Clear permanently deletes every message sent to the inbox.
+func (i *Inbox) Clear(ctx context.Context) error {
+	_, err := i.client.DeleteMatching(ctx, i.lookup())
+	return err
+}
+
+// Wait polls the inbox until a message matching every filter arrives and returns it, the most recent match is
+// returned if there are several. With no filters any message matches. Wait gives up with the context's error when ctx
+// is done, so callers should always set a deadline.
+func (i *Inbox) Wait(ctx context.Context, filters ...MessageFilter) (*Message, error) {
+	ticker := time.NewTicker(i.client.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		summaries, err := i.All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range summaries {
+			if matchesFilters(summary, filters) {
+				return i.client.getMessage(ctx, summary.Id)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func matchesFilters(summary *MessageSummary, filters []MessageFilter) bool {
+	for _, filter := range filters {
+		if !filter(summary) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+package mailosaur_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeMailServer is a fake mailosaur API that stores delivered messages, supporting search by recipient, retrieval
+// and deletion of single messages.
+type FakeMailServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages []map[string]interface{}
+}
+
+// NewFakeMailServer starts an empty FakeMailServer.
+func NewFakeMailServer(t *testing.T) *FakeMailServer {
+	f := &FakeMailServer{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id := strings.TrimPrefix(r.URL.Path, "/messages/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/messages/search":
+			var lookup mailosaur.SearchMessagesLookup
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&lookup))
+			perPage, err := strconv.Atoi(r.URL.Query().Get("itemsPerPage"))
+			if err != nil {
+				perPage = 50
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			var matched []map[string]interface{}
+			for i := len(f.messages) - 1; i >= 0; i-- {
+				msg := f.messages[i]
+				if lookup.SentTo == "" || msg["to"].([]map[string]string)[0]["email"] == lookup.SentTo {
+					matched = append(matched, msg)
+				}
+			}
+			items := []map[string]interface{}{}
+			for i := page * perPage; i < len(matched) && i < (page+1)*perPage; i++ {
+				items = append(items, matched[i])
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"items": items}))
+		case r.Method == http.MethodGet:
+			for _, msg := range f.messages {
+				if msg["id"] == id {
+					require.NoError(t, json.NewEncoder(w).Encode(msg))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			for i, msg := range f.messages {
+				if msg["id"] == id {
+					f.messages = append(f.messages[:i], f.messages[i+1:]...)
+					break
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return f
+}
+
+// Deliver stores a new message sent to the address, returning its id.
+func (f *FakeMailServer) Deliver(to string, subject string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := RandomMessageID()
+	f.messages = append(f.messages, map[string]interface{}{
+		"id":      id,
+		"from":    []map[string]string{{"email": "noreply@example.com"}},
+		"to":      []map[string]string{{"email": to}},
+		"subject": subject,
+	})
+	return id
+}
+
+func TestInbox(t *testing.T) {
+	type testSetup struct {
+		server *FakeMailServer
+		client *mailosaur.Client
+		inbox  *mailosaur.Inbox
+	}
+
+	setup := func(t *testing.T) *testSetup {
+		t.Parallel()
+		f := NewFakeMailServer(t)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(f.URL),
+			mailosaur.SetPollInterval(5*time.Millisecond))
+		return &testSetup{server: f, client: c, inbox: c.NewInbox()}
+	}
+
+	t.Run("has unique address on configured server", func(t *testing.T) {
+		ts := setup(t)
+		other := ts.client.NewInbox()
+		require.NotEqual(t, ts.inbox.Address(), other.Address())
+		require.True(t, strings.HasSuffix(ts.inbox.Address(), "@"+mailosaur.SMTPHost))
+	})
+
+	t.Run("includes sanitised name in address", func(t *testing.T) {
+		ts := setup(t)
+		inbox := ts.client.NewInbox(mailosaur.SetInboxName("TestSignup/Sends Welcome_Email"))
+		require.True(t, strings.HasPrefix(inbox.Address(), "testsignup-sends-welcome-email-"), inbox.Address())
+	})
+
+	t.Run("only sees its own messages", func(t *testing.T) {
+		ts := setup(t)
+		ts.server.Deliver(ts.inbox.Address(), "mine")
+		ts.server.Deliver(ts.client.NewInbox().Address(), "theirs")
+
+		all, err := ts.inbox.All(context.Background())
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		require.Equal(t, "mine", all[0].Subject)
+
+		count, err := ts.inbox.Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("returns latest message", func(t *testing.T) {
+		ts := setup(t)
+		ts.server.Deliver(ts.inbox.Address(), "first")
+		id := ts.server.Deliver(ts.inbox.Address(), "second")
+
+		msg, err := ts.inbox.Latest(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, id, msg.Id)
+		require.Equal(t, "second", msg.Subject)
+	})
+
+	t.Run("latest of empty inbox", func(t *testing.T) {
+		ts := setup(t)
+		_, err := ts.inbox.Latest(context.Background())
+		require.Equal(t, mailosaur.ErrInboxEmpty, err)
+	})
+
+	t.Run("clear deletes only its own messages", func(t *testing.T) {
+		ts := setup(t)
+		other := ts.client.NewInbox()
+		ts.server.Deliver(ts.inbox.Address(), "mine")
+		ts.server.Deliver(other.Address(), "theirs")
+
+		require.NoError(t, ts.inbox.Clear(context.Background()))
+		count, err := ts.inbox.Count(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, count)
+		count, err = other.Count(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("waits for matching message", func(t *testing.T) {
+		ts := setup(t)
+		ts.server.Deliver(ts.inbox.Address(), "Welcome")
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			ts.server.Deliver(ts.inbox.Address(), "Reset your password")
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		msg, err := ts.inbox.Wait(ctx, mailosaur.SubjectContains("password"), mailosaur.SentFrom("noreply@example.com"))
+		require.NoError(t, err)
+		require.Equal(t, "Reset your password", msg.Subject)
+	})
+
+	t.Run("wait gives up when context is done", func(t *testing.T) {
+		ts := setup(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := ts.inbox.Wait(ctx)
+		require.Error(t, err)
+		require.Equal(t, context.DeadlineExceeded, ctx.Err())
+	})
+
+	t.Run("wait uses default poll interval for non-positive intervals", func(t *testing.T) {
+		t.Parallel()
+		for _, interval := range []time.Duration{0, -time.Second} {
+			f := NewFakeMailServer(t)
+			c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(f.URL),
+				mailosaur.SetPollInterval(interval))
+			inbox := c.NewInbox()
+			f.Deliver(inbox.Address(), "Welcome")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			msg, err := inbox.Wait(ctx)
+			cancel()
+			require.NoError(t, err)
+			require.Equal(t, "Welcome", msg.Subject)
+		}
+	})
+
+	t.Run("wait fails on api error", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewTestHTTPServer(t, &TestResponse{StatusCode: http.StatusUnauthorized})
+		defer s.Close()
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := c.NewInbox().Wait(ctx)
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		require.NoError(t, ctx.Err())
+	})
+}
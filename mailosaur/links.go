@@ -0,0 +1,250 @@
+package mailosaur
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultMaxRedirects is the number of redirects CheckLinks follows from each link unless configured otherwise.
+const defaultMaxRedirects = 10
+
+// linkCheckOption configures CheckLinks
+type linkCheckOption func(*linkCheckOptions)
+
+type linkCheckOptions struct {
+	client       *http.Client
+	concurrency  int
+	maxRedirects int
+	skipDomains  []string
+}
+
+// SetLinkCheckClient sets the http.Client CheckLinks visits links with, http.DefaultClient is used if client is nil.
+// The client's own redirect policy is ignored in favour of SetMaxRedirects.
+func SetLinkCheckClient(client *http.Client) linkCheckOption {
+	return func(o *linkCheckOptions) {
+		o.client = client
+	}
+}
+
+// SetLinkCheckConcurrency sets the maximum number of links CheckLinks visits in parallel, defaults to 4.
+func SetLinkCheckConcurrency(concurrency int) linkCheckOption {
+	return func(o *linkCheckOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// SetMaxRedirects sets the number of redirects CheckLinks follows from each link before reporting it as failed,
+// defaults to 10. Zero or a negative value reports any redirect as a failure.
+func SetMaxRedirects(maxRedirects int) linkCheckOption {
+	return func(o *linkCheckOptions) {
+		o.maxRedirects = maxRedirects
+	}
+}
+
+// SetSkipDomains makes CheckLinks skip links to the domains, and their subdomains, e.g. hosts that block automated
+// requests or that must not see test traffic.
+func SetSkipDomains(domains ...string) linkCheckOption {
+	return func(o *linkCheckOptions) {
+		o.skipDomains = append(o.skipDomains, domains...)
+	}
+}
+
+// LinkResult is the outcome of visiting a single link.
+type LinkResult struct {
+	URL string
+	// StatusCode is the status of the final response, after following any redirects.
+	StatusCode int
+	// Redirects lists every URL the link redirected to, in order, the last is the final destination. Redirects to
+	// URLs that would be skipped are listed but not followed, StatusCode is then the status of the redirect.
+	Redirects []string
+	// Skipped is set for links that were not visited, because they are not http(s) URLs or are on a skipped domain.
+	Skipped bool
+	Err     error
+}
+
+// Failed reports whether the link could not be visited or its final response was an error.
+func (r *LinkResult) Failed() bool {
+	return !r.Skipped && (r.Err != nil || r.StatusCode >= http.StatusBadRequest)
+}
+
+// LinkReport holds the result of checking every link in a message.
+type LinkReport struct {
+	Results []*LinkResult
+}
+
+// BrokenLinksError lists the failed links of a LinkReport.
+type BrokenLinksError struct {
+	Links []*LinkResult
+}
+
+func (e *BrokenLinksError) Error() string {
+	links := make([]string, 0, len(e.Links))
+	for _, link := range e.Links {
+		if link.Err != nil {
+			links = append(links, fmt.Sprintf("%s: %v", link.URL, link.Err))
+		} else {
+			links = append(links, fmt.Sprintf("%s: status %d", link.URL, link.StatusCode))
+		}
+	}
+	return fmt.Sprintf("mailosaur: %d broken link(s): %s", len(e.Links), strings.Join(links, "; "))
+}
+
+// Err returns a *BrokenLinksError listing every failed link, or nil if there are none.
+func (r *LinkReport) Err() error {
+	var broken []*LinkResult
+	for _, result := range r.Results {
+		if result.Failed() {
+			broken = append(broken, result)
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+	return &BrokenLinksError{Links: broken}
+}
+
+// messageLinks returns the distinct links in the HTML and text bodies of a message, in order of appearance.
+func messageLinks(msg *Message) []string {
+	seen := map[string]bool{}
+	var links []string
+	for _, content := range []*MessageContent{msg.HTML, msg.Text} {
+		if content == nil {
+			continue
+		}
+		for _, link := range content.Links {
+			if !seen[link.Href] {
+				seen[link.Href] = true
+				links = append(links, link.Href)
+			}
+		}
+	}
+	return links
+}
+
+// CheckLinks visits every distinct link in the HTML and text bodies of a message and reports any that are broken.
+// Each link is requested with HEAD, falling back to GET if that fails, and redirects are followed up to a limit.
+// Redirects to other schemes or skipped domains are not followed. Results are in the order links appear in the
+// message.
+func CheckLinks(ctx context.Context, msg *Message, options ...linkCheckOption) *LinkReport {
+	o := &linkCheckOptions{client: http.DefaultClient, concurrency: defaultConcurrency, maxRedirects: defaultMaxRedirects}
+	for _, opt := range options {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	if o.maxRedirects < 0 {
+		o.maxRedirects = 0
+	}
+	client := noFollowClient(o.client)
+
+	links := messageLinks(msg)
+	report := &LinkReport{Results: make([]*LinkResult, len(links))}
+	for i, link := range links {
+		report.Results[i] = &LinkResult{URL: link}
+	}
+	errs := forEach(ctx, len(links), o.concurrency, func(ctx context.Context, i int) error {
+		o.visit(ctx, client, report.Results[i])
+		return nil
+	})
+	for i, err := range errs {
+		if err != nil {
+			// the link was not visited before ctx ended
+			report.Results[i].Err = err
+		}
+	}
+	return report
+}
+
+// noFollowClient returns a copy of client, or of http.DefaultClient if client is nil, that returns redirect responses
+// instead of following them, so visit can apply its own redirect policy.
+func noFollowClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	noFollow := *client
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &noFollow
+}
+
+// skip reports whether a link should not be visited.
+func (o *linkCheckOptions) skip(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return true
+	}
+	return inDomains(u.Hostname(), o.skipDomains)
+}
+
+// inDomains reports whether host is one of the domains, or a subdomain of one.
+func inDomains(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// visit follows a link through any redirects, recording the outcome in result.
+func (o *linkCheckOptions) visit(ctx context.Context, client *http.Client, result *LinkResult) {
+	u, err := url.Parse(result.URL)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	if o.skip(u) {
+		result.Skipped = true
+		return
+	}
+
+	for {
+		resp, err := doLinkRequest(ctx, client, http.MethodHead, u)
+		if err != nil || resp.StatusCode >= http.StatusBadRequest {
+			// many servers reject or mishandle HEAD requests
+			resp, err = doLinkRequest(ctx, client, http.MethodGet, u)
+		}
+		if err != nil {
+			result.Err = err
+			return
+		}
+		result.StatusCode = resp.StatusCode
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			return
+		}
+		if len(result.Redirects) >= o.maxRedirects {
+			result.Err = fmt.Errorf("stopped after %d redirects", o.maxRedirects)
+			return
+		}
+		if u, err = u.Parse(location); err != nil {
+			result.Err = fmt.Errorf("invalid redirect location %q: %v", location, err)
+			return
+		}
+		result.Redirects = append(result.Redirects, u.String())
+		if o.skip(u) {
+			return
+		}
+	}
+}
+
+// doLinkRequest makes a single request for a link, discarding the response body.
+func doLinkRequest(ctx context.Context, client *http.Client, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
@@ -0,0 +1,188 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+// LinksMessage returns a message whose HTML body links to each href.
+func LinksMessage(hrefs ...string) *mailosaur.Message {
+	content := &mailosaur.MessageContent{}
+	for _, href := range hrefs {
+		content.Links = append(content.Links, &mailosaur.Link{Href: href})
+	}
+	return &mailosaur.Message{HTML: content}
+}
+
+// NewLinksHTTPServer starts a server with working, broken and redirecting pages.
+func NewLinksHTTPServer(t *testing.T) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/redirect":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/to-mailto":
+			w.Header().Set("Location", "mailto:someone@example.com")
+			w.WriteHeader(http.StatusFound)
+		case "/to-localhost":
+			w.Header().Set("Location", "http://localhost:"+r.Host[strings.LastIndexByte(r.Host, ':')+1:]+"/ok")
+			w.WriteHeader(http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestCheckLinks(t *testing.T) {
+	t.Run("reports status of each link", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+		msg := LinksMessage(s.URL+"/ok", s.URL+"/get-only", s.URL+"/missing")
+		msg.Text = &mailosaur.MessageContent{Links: []*mailosaur.Link{{Href: s.URL + "/ok"}}}
+
+		report := mailosaur.CheckLinks(context.Background(), msg, mailosaur.SetLinkCheckClient(s.Client()))
+		require.Len(t, report.Results, 3)
+		require.Equal(t, http.StatusOK, report.Results[0].StatusCode)
+		require.False(t, report.Results[0].Failed())
+		require.Equal(t, http.StatusOK, report.Results[1].StatusCode)
+		require.Equal(t, http.StatusNotFound, report.Results[2].StatusCode)
+
+		var brokenErr *mailosaur.BrokenLinksError
+		require.True(t, errors.As(report.Err(), &brokenErr))
+		require.Equal(t, []*mailosaur.LinkResult{report.Results[2]}, brokenErr.Links)
+		require.EqualError(t, report.Err(), "mailosaur: 1 broken link(s): "+s.URL+"/missing: status 404")
+	})
+
+	t.Run("follows redirects", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage(s.URL+"/redirect"),
+			mailosaur.SetLinkCheckClient(s.Client()))
+		require.NoError(t, report.Err())
+		require.Equal(t, http.StatusOK, report.Results[0].StatusCode)
+		require.Equal(t, []string{s.URL + "/moved", s.URL + "/ok"}, report.Results[0].Redirects)
+	})
+
+	t.Run("limits redirects", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage(s.URL+"/loop"),
+			mailosaur.SetLinkCheckClient(s.Client()), mailosaur.SetMaxRedirects(3))
+		require.True(t, report.Results[0].Failed())
+		require.EqualError(t, report.Results[0].Err, "stopped after 3 redirects")
+		require.Len(t, report.Results[0].Redirects, 3)
+
+		report = mailosaur.CheckLinks(context.Background(), LinksMessage(s.URL+"/loop"),
+			mailosaur.SetLinkCheckClient(s.Client()), mailosaur.SetMaxRedirects(-1))
+		require.EqualError(t, report.Results[0].Err, "stopped after 0 redirects")
+		require.Empty(t, report.Results[0].Redirects)
+	})
+
+	t.Run("uses default client when nil", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage(s.URL+"/ok"), mailosaur.SetLinkCheckClient(nil))
+		require.NoError(t, report.Err())
+		require.Equal(t, http.StatusOK, report.Results[0].StatusCode)
+	})
+
+	t.Run("skips allowlisted domains and other schemes", func(t *testing.T) {
+		t.Parallel()
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage("https://www.example.com/account",
+			"https://example.com", "mailto:help@example.com", "tel:+15550100"), mailosaur.SetSkipDomains("Example.com"))
+		for _, result := range report.Results {
+			require.True(t, result.Skipped, result.URL)
+		}
+		require.NoError(t, report.Err())
+	})
+
+	t.Run("does not follow redirects to skipped links", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage(s.URL+"/to-mailto", s.URL+"/to-localhost"),
+			mailosaur.SetLinkCheckClient(s.Client()), mailosaur.SetSkipDomains("localhost"))
+		require.NoError(t, report.Err())
+		require.Equal(t, []string{"mailto:someone@example.com"}, report.Results[0].Redirects)
+		require.Equal(t, http.StatusFound, report.Results[0].StatusCode)
+		require.Len(t, report.Results[1].Redirects, 1)
+		require.Equal(t, http.StatusFound, report.Results[1].StatusCode)
+	})
+
+	t.Run("reports unreachable links", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+		s.Close()
+
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage(s.URL+"/ok"))
+		require.True(t, report.Results[0].Failed())
+		require.Error(t, report.Results[0].Err)
+	})
+
+	t.Run("reports links not visited before context ends", func(t *testing.T) {
+		t.Parallel()
+		s := NewLinksHTTPServer(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report := mailosaur.CheckLinks(ctx, LinksMessage(s.URL+"/ok", s.URL+"/redirect"),
+			mailosaur.SetLinkCheckClient(s.Client()))
+		for _, result := range report.Results {
+			require.Equal(t, context.Canceled, result.Err, result.URL)
+		}
+	})
+
+	t.Run("bounds concurrency", func(t *testing.T) {
+		t.Parallel()
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}))
+		defer s.Close()
+
+		var hrefs []string
+		for i := 0; i < 12; i++ {
+			hrefs = append(hrefs, s.URL+"/"+RandomMessageID())
+		}
+		report := mailosaur.CheckLinks(context.Background(), LinksMessage(hrefs...),
+			mailosaur.SetLinkCheckClient(s.Client()), mailosaur.SetLinkCheckConcurrency(3))
+		require.NoError(t, report.Err())
+		require.Len(t, report.Results, 12)
+		mu.Lock()
+		defer mu.Unlock()
+		require.LessOrEqual(t, maxInFlight, 3)
+		require.Greater(t, maxInFlight, 1)
+	})
+}
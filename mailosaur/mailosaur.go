@@ -3,10 +3,12 @@ package mailosaur
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
 	"strings"
 	"time"
@@ -15,15 +17,34 @@ import (
 const (
 	// ServiceURL provides the default service url for the mailosaur API
 	ServiceURL = "https://mailosaur.com/api"
-	SMTPHost   = "mailosaur.io"
+	// SMTPHost is the default mail domain of mailosaur servers, see SetMailDomain
+	SMTPHost = "mailosaur.io"
+
+	// Version is the version of this client library, reported to the mailosaur API in the User-Agent header.
+	Version = "0.1.0"
+
+	// RequestIDHeader is the header used to send a unique correlation id with every request to the mailosaur API.
+	RequestIDHeader = "X-Request-Id"
 )
 
+// userAgent is the default User-Agent header sent with every request.
+const userAgent = "mailosaur-go/" + Version
+
 // Client provides impelmentations of the mailosaur API
 type Client struct {
 	serverID   string
 	apiKey     string
 	serviceURL string
+	userAgent  string
 	http       http.Client
+
+	pollInterval time.Duration
+	addresses    *addressGenerator
+
+	breaker     *circuitBreaker
+	circuitHook func(from, to CircuitState)
+	cache       *lruCache
+	limiter     *rateLimiter
 }
 
 // clientOption is an option function that configures the mailosaur client
@@ -36,34 +57,144 @@ func SetServiceURL(serviceURL string) clientOption {
 	}
 }
 
+// SetUserAgentSuffix appends an application specific product token, e.g. "myapp/1.2", to the User-Agent header sent
+// with every request.
+func SetUserAgentSuffix(suffix string) clientOption {
+	return func(c *Client) {
+		c.userAgent += " " + strings.TrimSpace(suffix)
+	}
+}
+
+// SetHTTPTransport overrides the http.RoundTripper used to make requests to the mailosaur API, e.g. to record or
+// replay API traffic in tests.
+func SetHTTPTransport(transport http.RoundTripper) clientOption {
+	return func(c *Client) {
+		c.http.Transport = transport
+	}
+}
+
 // NewClient creates, configures, and returns a new mailosaur Client
 func NewClient(apiKey string, serverID string, options ...clientOption) *Client {
 	c := &Client{
 		apiKey:     apiKey,
 		serverID:   serverID,
 		serviceURL: ServiceURL,
+		userAgent:  userAgent,
+
+		pollInterval: defaultPollInterval,
+		addresses:    newAddressGenerator(),
 	}
 	for _, opt := range options {
 		opt(c)
 	}
+	c.addresses.compile()
+	if c.breaker != nil {
+		c.breaker.hook = c.circuitHook
+	}
 	return c
 }
 
 // Call constructs a request to the mailosaur API, applying necessary authorization and request headers to make a
-// successful API call.
+// successful API call. Every request carries a unique id in the RequestIDHeader, use RequestID to read it back from
+// the returned response. Unlike the typed API methods, Call does not treat non-2xx responses as errors.
 func (c *Client) Call(method string, path string, queryParams map[string]interface{}, data interface{}) (*http.Response, error) {
-	req, err := http.NewRequest(method, c.serviceURL+"/"+path, nil)
+	return c.CallContext(context.Background(), method, path, queryParams, data)
+}
+
+// CallContext is like Call but the request is bound to ctx, it is abandoned if ctx is cancelled before a response is
+// received or while waiting on the client's rate limit.
+func (c *Client) CallContext(ctx context.Context, method string, path string, queryParams map[string]interface{}, data interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.serviceURL+"/"+path, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	setAuthorization(req, c.apiKey)
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set(RequestIDHeader, newRequestID())
 	setQueryParams(req, queryParams)
 	if err := setJSONData(req, data); err != nil {
 		return nil, err
 	}
 
-	return c.http.Do(req)
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if c.breaker == nil {
+		return c.http.Do(req)
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil && ctx.Err() != nil {
+		// the caller gave up on the request, which says nothing about the health of the API
+		c.breaker.release()
+		return resp, err
+	}
+	c.breaker.record(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, err
+}
+
+// do is like CallContext but fails with an *APIError when the API responds with a non-2xx status, it is used by every
+// typed API method.
+func (c *Client) do(ctx context.Context, method string, path string, queryParams map[string]interface{}, data interface{}) (*http.Response, error) {
+	resp, err := c.CallContext(ctx, method, path, queryParams, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// maxErrorBody limits how much of an error response body is kept in an APIError.
+const maxErrorBody = 4096
+
+// APIError is returned by the typed API methods when the mailosaur API responds with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Status     string
+	// Body is the start of the response body, which usually describes the error.
+	Body []byte
+	// RequestID is the correlation id sent with the failed request, quote it when contacting mailosaur support.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("mailosaur: %s (request id %s)", e.Status, e.RequestID)
+	if body := strings.TrimSpace(string(e.Body)); body != "" {
+		msg += ": " + body
+	}
+	return msg
+}
+
+// checkResponse returns an *APIError if resp has a non-2xx status, reading and closing its body.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+		RequestID:  RequestID(resp),
+	}
+}
+
+// decodeJSON reads and closes the response body, decoding it into v.
+func decodeJSON(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
 }
 
 // setAuthorization provides authorization headers used by the mailosaur API. The API requires HTTP basic auth via a
@@ -72,6 +203,27 @@ func setAuthorization(req *http.Request, apiKey string) {
 	req.SetBasicAuth(apiKey, "")
 }
 
+// RequestID returns the correlation id that was sent with the request that produced resp, for matching client logs
+// with mailosaur's. Returns an empty string if resp did not come from Call. Typed API methods that fail with an
+// *APIError carry the id in its RequestID field.
+func RequestID(resp *http.Response) string {
+	if resp == nil || resp.Request == nil {
+		return ""
+	}
+	return resp.Request.Header.Get(RequestIDHeader)
+}
+
+// newRequestID generates a random version 4 UUID used to correlate a single API request.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
 // setQueryParams sets the query string for a given request based on the provided parameters.
 func setQueryParams(req *http.Request, params map[string]interface{}) {
 	query := req.URL.Query()
@@ -94,20 +246,3 @@ func setJSONData(req *http.Request, data interface{}) error {
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	return nil
 }
-
-func randomStr(n int) string {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	chars := "abcdefghijklmnopqrstuvwxyz"
-	charLen := len(chars)
-
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = chars[r.Intn(charLen)]
-	}
-	return string(b)
-}
-
-// GenerateEmail returns a random valid email address for the configured mailosaur server.
-func (c *Client) GenerateEmail() string {
-	return fmt.Sprintf("%s.%s@%s", randomStr(10), c.serverID, SMTPHost)
-}
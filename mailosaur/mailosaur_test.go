@@ -146,6 +146,41 @@ func TestCall(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "msgID="+msgID, ts.recvReq.URL.Query().Encode())
 	})
+
+	t.Run("sends versioned user agent", func(t *testing.T) {
+		ts := setup(t)
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "mailosaur-go/"+mailosaur.Version, ts.recvReq.Headers.Get("User-Agent"))
+	})
+
+	t.Run("appends user agent suffix", func(t *testing.T) {
+		s, recvReq := NewTestHTTPServer(t, &TestResponse{StatusCode: 200})
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetUserAgentSuffix("myapp/1.2"))
+		_, err := c.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "mailosaur-go/"+mailosaur.Version+" myapp/1.2", recvReq.Headers.Get("User-Agent"))
+	})
+
+	t.Run("sends unique request id", func(t *testing.T) {
+		ts := setup(t)
+		_, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		first := ts.recvReq.Headers.Get(mailosaur.RequestIDHeader)
+		require.Len(t, first, 36)
+
+		_, err = ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.NotEqual(t, first, ts.recvReq.Headers.Get(mailosaur.RequestIDHeader))
+	})
+
+	t.Run("exposes request id on response", func(t *testing.T) {
+		ts := setup(t)
+		resp, err := ts.client.Call(http.MethodGet, "path", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, ts.recvReq.Headers.Get(mailosaur.RequestIDHeader), mailosaur.RequestID(resp))
+	})
 }
 
 func TestGenerateEmail(t *testing.T) {
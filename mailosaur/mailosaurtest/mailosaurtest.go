@@ -0,0 +1,51 @@
+// mailosaurtest provides helpers for using mailosaur from go tests.
+package mailosaurtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+)
+
+// CleanupTimeout bounds how long the cleanup registered by NewInbox may spend talking to the mailosaur API.
+var CleanupTimeout = 30 * time.Second
+
+// NewInbox creates an Inbox whose address is named after the test, making its mail easy to find in the mailosaur
+// dashboard. When the test finishes, only the messages sent to the inbox are deleted, and if the test failed a summary
+// of each received message is logged first to help diagnose the failure.
+func NewInbox(t testing.TB, client *mailosaur.Client) *mailosaur.Inbox {
+	t.Helper()
+	inbox := client.NewInbox(mailosaur.SetInboxName(t.Name()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), CleanupTimeout)
+		defer cancel()
+
+		if t.Failed() {
+			logMessages(ctx, t, inbox)
+		}
+		if err := inbox.Clear(ctx); err != nil {
+			t.Errorf("mailosaurtest: clearing inbox %s: %v", inbox.Address(), err)
+		}
+	})
+	return inbox
+}
+
+// logMessages logs a summary of every message received by inbox.
+func logMessages(ctx context.Context, t testing.TB, inbox *mailosaur.Inbox) {
+	summaries, err := inbox.All(ctx)
+	if err != nil {
+		t.Logf("mailosaurtest: listing messages sent to %s: %v", inbox.Address(), err)
+		return
+	}
+	t.Logf("mailosaurtest: %d message(s) sent to %s", len(summaries), inbox.Address())
+	for _, summary := range summaries {
+		from := ""
+		if len(summary.From) > 0 {
+			from = summary.From[0]["email"]
+		}
+		t.Logf("  %s from %q subject %q received %s", summary.Id, from, summary.Subject,
+			summary.Received.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,128 @@
+package mailosaurtest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/jslang/mailosaur-go/mailosaur/mailosaurtest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is a fake mailosaur API holding messages keyed by id, each sent to a single address.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages map[string]string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	f := &fakeServer{messages: map[string]string{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPost:
+			var lookup mailosaur.SearchMessagesLookup
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&lookup))
+			items := []string{}
+			for id, to := range f.messages {
+				if to == lookup.SentTo {
+					items = append(items, fmt.Sprintf(`{"id": %q, "subject": "Welcome", "from": [{"email": "app@example.com"}]}`, id))
+				}
+			}
+			_, err := fmt.Fprintf(w, `{"items": [%s]}`, strings.Join(items, ","))
+			require.NoError(t, err)
+		case http.MethodDelete:
+			delete(f.messages, strings.TrimPrefix(r.URL.Path, "/messages/"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	return f
+}
+
+func (f *fakeServer) deliver(id string, to string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages[id] = to
+}
+
+func (f *fakeServer) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.messages)
+}
+
+// recordingTB wraps a testing.TB, capturing logs and cleanups and reporting the test as failed on demand.
+type recordingTB struct {
+	testing.TB
+	failed   bool
+	logs     []string
+	cleanups []func()
+}
+
+func (r *recordingTB) Helper()                       {}
+func (r *recordingTB) Name() string                  { return "TestSignup/sends_welcome" }
+func (r *recordingTB) Failed() bool                  { return r.failed }
+func (r *recordingTB) Cleanup(fn func())             { r.cleanups = append(r.cleanups, fn) }
+func (r *recordingTB) Errorf(string, ...interface{}) { r.failed = true }
+func (r *recordingTB) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) runCleanups() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestNewInbox(t *testing.T) {
+	t.Run("names address after test", func(t *testing.T) {
+		f := newFakeServer(t)
+		defer f.Close()
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(f.URL))
+
+		tb := &recordingTB{TB: t}
+		inbox := mailosaurtest.NewInbox(tb, c)
+		require.True(t, strings.HasPrefix(inbox.Address(), "testsignup-sends-welcome-"), inbox.Address())
+	})
+
+	t.Run("deletes only inbox messages on cleanup", func(t *testing.T) {
+		f := newFakeServer(t)
+		defer f.Close()
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(f.URL))
+
+		tb := &recordingTB{TB: t}
+		inbox := mailosaurtest.NewInbox(tb, c)
+		f.deliver("mine", inbox.Address())
+		f.deliver("theirs", c.GenerateEmail())
+
+		tb.runCleanups()
+		require.False(t, tb.failed)
+		require.Equal(t, 1, f.count())
+		require.Empty(t, tb.logs)
+	})
+
+	t.Run("logs received messages on failure", func(t *testing.T) {
+		f := newFakeServer(t)
+		defer f.Close()
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(f.URL))
+
+		tb := &recordingTB{TB: t, failed: true}
+		inbox := mailosaurtest.NewInbox(tb, c)
+		f.deliver("mine", inbox.Address())
+
+		tb.runCleanups()
+		require.Len(t, tb.logs, 2)
+		require.Contains(t, tb.logs[0], "1 message(s) sent to "+inbox.Address())
+		require.Contains(t, tb.logs[1], `from "app@example.com" subject "Welcome"`)
+		require.Zero(t, f.count())
+	})
+}
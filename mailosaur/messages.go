@@ -1,9 +1,14 @@
 package mailosaur
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/mail"
+	"strings"
 	"time"
 )
 
@@ -39,26 +44,52 @@ func applyMessageListOptions(data map[string]interface{}, options []messageListO
 
 // GetMessage retrieves the detail for a single email message.
 func (c *Client) GetMessage(messageID string) (*Message, error) {
-	httpResp, err := c.Call(http.MethodGet, "messages/"+messageID, nil, nil)
+	return c.getMessage(context.Background(), messageID)
+}
+
+func (c *Client) getMessage(ctx context.Context, messageID string) (*Message, error) {
+	var msg Message
+	if c.cache != nil {
+		if body, ok := c.cache.get(messageCacheKey + messageID); ok {
+			if err := json.Unmarshal(body, &msg); err != nil {
+				return nil, err
+			}
+			return &msg, nil
+		}
+	}
+
+	httpResp, err := c.do(ctx, http.MethodGet, "messages/"+messageID, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	var msg Message
+	defer httpResp.Body.Close()
 	body, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
 		return nil, err
 	}
-
-	return &msg, json.Unmarshal(body, &msg)
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		c.cache.set(messageCacheKey+messageID, body)
+	}
+	return &msg, nil
 }
 
 // DeleteMessage permanently deletes a message.
 func (c *Client) DeleteMessage(messageID string) error {
-	_, err := c.Call(http.MethodDelete, "messages/"+messageID, nil, nil)
+	return c.deleteMessage(context.Background(), messageID)
+}
+
+func (c *Client) deleteMessage(ctx context.Context, messageID string) error {
+	httpResp, err := c.do(ctx, http.MethodDelete, "messages/"+messageID, nil, nil)
+	// invalidated only once the call returns, a concurrent GetMessage could otherwise cache the message again while the
+	// delete is in flight
+	c.invalidateMessage(messageID)
 	if err != nil {
 		return err
 	}
-	return nil
+	return httpResp.Body.Close()
 }
 
 type (
@@ -76,27 +107,34 @@ func (c *Client) ListMessages(options ...messageListOption) ([]*MessageSummary,
 	}
 	applyMessageListOptions(queryParams, options)
 
-	httpResp, err := c.Call(http.MethodGet, "messages", queryParams, nil)
+	httpResp, err := c.do(context.Background(), http.MethodGet, "messages", queryParams, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer httpResp.Body.Close()
 	var resp struct{ Items []*MessageSummary }
 	body, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Items, json.Unmarshal(body, &resp)
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
 }
 
 // DeleteMessages permanently deletes all messages held by the specified server.
 func (c *Client) DeleteMessages() error {
-	_, err := c.Call(http.MethodDelete, "messages", map[string]interface{}{
+	httpResp, err := c.do(context.Background(), http.MethodDelete, "messages", map[string]interface{}{
 		"server": c.serverID,
 	}, nil)
+	if c.cache != nil {
+		c.cache.removePrefix("")
+	}
 	if err != nil {
 		return err
 	}
-	return nil
+	return httpResp.Body.Close()
 }
 
 // SearchMessagesLookup defines the search parameters for a SearchMessages call.
@@ -108,19 +146,145 @@ type SearchMessagesLookup struct {
 
 // SearchMessages returns a list of message summaries matching the specified search criteria.
 func (c *Client) SearchMessages(lookup *SearchMessagesLookup, options ...messageListOption) ([]*MessageSummary, error) {
+	return c.searchMessages(context.Background(), lookup, options...)
+}
+
+func (c *Client) searchMessages(ctx context.Context, lookup *SearchMessagesLookup, options ...messageListOption) ([]*MessageSummary, error) {
 	queryParams := map[string]interface{}{
 		"server": c.serverID,
 	}
 	applyMessageListOptions(queryParams, options)
 
-	httpResp, err := c.Call(http.MethodPost, "messages/search", queryParams, lookup)
+	httpResp, err := c.do(ctx, http.MethodPost, "messages/search", queryParams, lookup)
 	if err != nil {
 		return nil, err
 	}
+	defer httpResp.Body.Close()
 	var resp struct{ Items []*MessageSummary }
 	body, err := ioutil.ReadAll(httpResp.Body)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Items, json.Unmarshal(body, &resp)
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// ForwardOptions defines the message forwarded by ForwardMessage.
+type ForwardOptions struct {
+	// To is the address the message is forwarded to, required.
+	To string `json:"to"`
+	// Text is optional plain text added above the forwarded message.
+	Text string `json:"text,omitempty"`
+	// HTML is optional HTML added above the forwarded message.
+	HTML string `json:"html,omitempty"`
+}
+
+// ForwardMessage forwards a received message to another address, returning the forwarded message.
+func (c *Client) ForwardMessage(ctx context.Context, messageID string, options ForwardOptions) (*Message, error) {
+	if options.To == "" {
+		return nil, errors.New("mailosaur: forwarding requires a To address")
+	}
+	return c.sendMessage(ctx, "messages/"+messageID+"/forward", nil, options)
+}
+
+// ReplyOptions defines the reply sent by ReplyToMessage.
+type ReplyOptions struct {
+	Text        string            `json:"text,omitempty"`
+	HTML        string            `json:"html,omitempty"`
+	Attachments []AttachmentInput `json:"attachments,omitempty"`
+}
+
+// ReplyToMessage sends a reply to the sender of a received message, returning the reply. At least one of Text or HTML
+// must be set, and every attachment needs a FileName, ContentType and Content. The options are validated before any
+// request is made.
+func (c *Client) ReplyToMessage(ctx context.Context, messageID string, options ReplyOptions) (*Message, error) {
+	var problems []string
+	if options.Text == "" && options.HTML == "" {
+		problems = append(problems, "Text or HTML is required")
+	}
+	problems = append(problems, validateAttachments(options.Attachments)...)
+	if len(problems) > 0 {
+		return nil, errors.New("mailosaur: invalid reply: " + strings.Join(problems, ", "))
+	}
+	return c.sendMessage(ctx, "messages/"+messageID+"/reply", nil, options)
+}
+
+// validateAttachments returns a problem for every field an attachment is missing that the API requires.
+func validateAttachments(attachments []AttachmentInput) []string {
+	var problems []string
+	for i, attachment := range attachments {
+		if attachment.FileName == "" {
+			problems = append(problems, fmt.Sprintf("attachment %d has no FileName", i))
+		}
+		if attachment.ContentType == "" {
+			problems = append(problems, fmt.Sprintf("attachment %d has no ContentType", i))
+		}
+		if len(attachment.Content) == 0 {
+			problems = append(problems, fmt.Sprintf("attachment %d has no Content", i))
+		}
+	}
+	return problems
+}
+
+// CreateMessageOptions defines a new message created by CreateMessage.
+type CreateMessageOptions struct {
+	// To is the address the message is sent to, required.
+	To string `json:"to"`
+	// Subject is the subject line of the message, required.
+	Subject string `json:"subject"`
+	// Text is the plain text body, at least one of Text or HTML is required.
+	Text string `json:"text,omitempty"`
+	// HTML is the HTML body, at least one of Text or HTML is required.
+	HTML        string            `json:"html,omitempty"`
+	Attachments []AttachmentInput `json:"attachments,omitempty"`
+	// Send delivers the message to To when true, otherwise the message is only created as a draft on the server.
+	Send bool `json:"send"`
+}
+
+// validate checks that the options describe a message the API will accept.
+func (o *CreateMessageOptions) validate() error {
+	var problems []string
+	if o.To == "" {
+		problems = append(problems, "To is required")
+	} else if _, err := mail.ParseAddress(o.To); err != nil {
+		problems = append(problems, fmt.Sprintf("To %q is not a valid address", o.To))
+	}
+	if o.Subject == "" {
+		problems = append(problems, "Subject is required")
+	}
+	if o.Text == "" && o.HTML == "" {
+		problems = append(problems, "Text or HTML is required")
+	}
+	problems = append(problems, validateAttachments(o.Attachments)...)
+	if len(problems) > 0 {
+		return errors.New("mailosaur: invalid message: " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// CreateMessage creates a new message on the configured server and, if Send is set, sends it from mailosaur to the To
+// address. This allows testing how an application handles inbound email. The options are validated before any request
+// is made.
+func (c *Client) CreateMessage(ctx context.Context, options CreateMessageOptions) (*Message, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	return c.sendMessage(ctx, "messages", map[string]interface{}{
+		"server": c.serverID,
+	}, options)
+}
+
+// sendMessage posts data to an endpoint that sends mail, decoding the sent message from the response.
+func (c *Client) sendMessage(ctx context.Context, path string, queryParams map[string]interface{}, data interface{}) (*Message, error) {
+	httpResp, err := c.do(ctx, http.MethodPost, path, queryParams, data)
+	if err != nil {
+		return nil, err
+	}
+	var msg Message
+	if err := decodeJSON(httpResp, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
 }
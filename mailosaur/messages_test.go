@@ -1,6 +1,8 @@
 package mailosaur_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -53,8 +55,29 @@ func TestGetMessage(t *testing.T) {
 		msg, err := ts.client.GetMessage(msgID)
 		require.NoError(t, err)
 		require.NotEmpty(t, msg)
+		require.Equal(t, "1.0", msg.Metadata.Headers.Get("mime-version"))
+		require.Equal(t, "Sign Up Now", msg.HTML.Links[0].Text)
+		require.Equal(t, "https://example.com/signup", msg.Text.Links[0].Href)
+	})
+
+	t.Run("returns api error with request id", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       []byte(`{"message": "Message not found"}`),
+			StatusCode: http.StatusNotFound,
+		})
+
+		msg, err := ts.client.GetMessage(RandomMessageID())
+		require.Nil(t, msg)
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		require.JSONEq(t, `{"message": "Message not found"}`, string(apiErr.Body))
+		require.Equal(t, ts.recvReq.Headers.Get(mailosaur.RequestIDHeader), apiErr.RequestID)
+		require.EqualError(t, err, `mailosaur: 404 Not Found (request id `+apiErr.RequestID+
+			`): {"message": "Message not found"}`)
 	})
 }
+
 func TestDeleteMessage(t *testing.T) {
 	type testSetup struct {
 		apiKey   string
@@ -328,3 +351,257 @@ func TestSearchMessages(t *testing.T) {
 		require.Len(t, messages, 1)
 	})
 }
+
+func TestForwardMessage(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("calls forward message endpoint", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msgID := RandomMessageID()
+		_, err := ts.client.ForwardMessage(context.Background(), msgID, mailosaur.ForwardOptions{To: "someone@example.com"})
+		require.NoError(t, err)
+		require.Equal(t, "/messages/"+msgID+"/forward", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodPost, ts.recvReq.Method)
+	})
+
+	t.Run("sends forward options", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		_, err := ts.client.ForwardMessage(context.Background(), RandomMessageID(), mailosaur.ForwardOptions{
+			To:   "someone@example.com",
+			Text: "FYI",
+			HTML: "<p>FYI</p>",
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"to": "someone@example.com", "text": "FYI", "html": "<p>FYI</p>"}`, string(ts.recvReq.Body))
+	})
+
+	t.Run("returns forwarded message", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msg, err := ts.client.ForwardMessage(context.Background(), RandomMessageID(), mailosaur.ForwardOptions{To: "someone@example.com"})
+		require.NoError(t, err)
+		require.Equal(t, "77061c9f-da47-4009-9f33-9715a3bbf00c", msg.Id)
+	})
+
+	t.Run("requires to address", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusOK})
+
+		_, err := ts.client.ForwardMessage(context.Background(), RandomMessageID(), mailosaur.ForwardOptions{Text: "FYI"})
+		require.Error(t, err)
+		require.Empty(t, ts.recvReq.Method)
+	})
+}
+
+func TestReplyToMessage(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("calls reply endpoint", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msgID := RandomMessageID()
+		_, err := ts.client.ReplyToMessage(context.Background(), msgID, mailosaur.ReplyOptions{Text: "Thanks"})
+		require.NoError(t, err)
+		require.Equal(t, "/messages/"+msgID+"/reply", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodPost, ts.recvReq.Method)
+	})
+
+	t.Run("sends base64 encoded attachments", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		_, err := ts.client.ReplyToMessage(context.Background(), RandomMessageID(), mailosaur.ReplyOptions{
+			HTML: "<p>Thanks</p>",
+			Attachments: []mailosaur.AttachmentInput{
+				{FileName: "notes.txt", ContentType: "text/plain", Content: []byte("hello")},
+			},
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"html": "<p>Thanks</p>",
+			"attachments": [{"fileName": "notes.txt", "contentType": "text/plain", "content": "aGVsbG8="}]
+		}`, string(ts.recvReq.Body))
+	})
+
+	t.Run("returns reply message", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msg, err := ts.client.ReplyToMessage(context.Background(), RandomMessageID(), mailosaur.ReplyOptions{Text: "Thanks"})
+		require.NoError(t, err)
+		require.Equal(t, "Email subject line", msg.Subject)
+	})
+
+	t.Run("requires content", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusOK})
+
+		_, err := ts.client.ReplyToMessage(context.Background(), RandomMessageID(), mailosaur.ReplyOptions{})
+		require.Error(t, err)
+		require.Empty(t, ts.recvReq.Method)
+	})
+
+	t.Run("validates attachments before sending", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusOK})
+
+		_, err := ts.client.ReplyToMessage(context.Background(), RandomMessageID(), mailosaur.ReplyOptions{
+			Text:        "Thanks",
+			Attachments: []mailosaur.AttachmentInput{{FileName: "notes.txt", Content: []byte("hello")}},
+		})
+		require.EqualError(t, err, "mailosaur: invalid reply: attachment 0 has no ContentType")
+		require.Empty(t, ts.recvReq.Method)
+	})
+
+	t.Run("returns api error", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusNotFound})
+
+		msg, err := ts.client.ReplyToMessage(context.Background(), RandomMessageID(), mailosaur.ReplyOptions{Text: "Thanks"})
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		require.Nil(t, msg)
+	})
+}
+
+func TestCreateMessage(t *testing.T) {
+	type testSetup struct {
+		serverID string
+		recvReq  *ReceivedRequest
+		client   *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		serverID := RandomServerID()
+		return &testSetup{
+			serverID: serverID,
+			recvReq:  recvReq,
+			client:   mailosaur.NewClient(RandomAPIKey(), serverID, mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	validOptions := func() mailosaur.CreateMessageOptions {
+		return mailosaur.CreateMessageOptions{
+			To:      "inbound@example.com",
+			Subject: "Hello",
+			Text:    "Hello there",
+			Send:    true,
+		}
+	}
+
+	t.Run("calls create message endpoint", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		_, err := ts.client.CreateMessage(context.Background(), validOptions())
+		require.NoError(t, err)
+		require.Equal(t, "/messages", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodPost, ts.recvReq.Method)
+		require.Equal(t, ts.serverID, ts.recvReq.URL.Query().Get("server"))
+	})
+
+	t.Run("sends message with base64 encoded attachments", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		options := validOptions()
+		options.HTML = "<p>Hello there</p>"
+		options.Attachments = []mailosaur.AttachmentInput{
+			{FileName: "invoice.pdf", ContentType: "application/pdf", Content: []byte("%PDF")},
+		}
+		_, err := ts.client.CreateMessage(context.Background(), options)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"to": "inbound@example.com",
+			"subject": "Hello",
+			"text": "Hello there",
+			"html": "<p>Hello there</p>",
+			"attachments": [{"fileName": "invoice.pdf", "contentType": "application/pdf", "content": "JVBERg=="}],
+			"send": true
+		}`, string(ts.recvReq.Body))
+	})
+
+	t.Run("returns created message", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "get_message_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msg, err := ts.client.CreateMessage(context.Background(), validOptions())
+		require.NoError(t, err)
+		require.Equal(t, "77061c9f-da47-4009-9f33-9715a3bbf00c", msg.Id)
+	})
+
+	t.Run("validates options before sending", func(t *testing.T) {
+		for name, modify := range map[string]func(*mailosaur.CreateMessageOptions){
+			"missing to":      func(o *mailosaur.CreateMessageOptions) { o.To = "" },
+			"invalid to":      func(o *mailosaur.CreateMessageOptions) { o.To = "not an address" },
+			"missing subject": func(o *mailosaur.CreateMessageOptions) { o.Subject = "" },
+			"missing body":    func(o *mailosaur.CreateMessageOptions) { o.Text = "" },
+			"attachment without name": func(o *mailosaur.CreateMessageOptions) {
+				o.Attachments = []mailosaur.AttachmentInput{{ContentType: "text/plain", Content: []byte("a")}}
+			},
+			"attachment without type": func(o *mailosaur.CreateMessageOptions) {
+				o.Attachments = []mailosaur.AttachmentInput{{FileName: "a.txt", Content: []byte("a")}}
+			},
+			"attachment without bytes": func(o *mailosaur.CreateMessageOptions) {
+				o.Attachments = []mailosaur.AttachmentInput{{FileName: "a.txt", ContentType: "text/plain"}}
+			},
+		} {
+			modify := modify
+			t.Run(name, func(t *testing.T) {
+				ts := setup(t, &TestResponse{StatusCode: http.StatusOK})
+				options := validOptions()
+				modify(&options)
+				_, err := ts.client.CreateMessage(context.Background(), options)
+				require.Error(t, err)
+				require.Empty(t, ts.recvReq.Method)
+			})
+		}
+	})
+}
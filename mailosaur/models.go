@@ -23,17 +23,58 @@ type baseMessage struct {
 type Message struct {
 	baseMessage
 
-	// TODO: implement explictly defined structs for below
-	Attachments json.RawMessage `json:"attachments"`
-	HTML        json.RawMessage `json:"html"`
-	Text        json.RawMessage `json:"text"`
-	Metadata    json.RawMessage `json:"metadata"`
+	Attachments []*Attachment   `json:"attachments"`
+	HTML        *MessageContent `json:"html"`
+	Text        *MessageContent `json:"text"`
+	Metadata    Metadata        `json:"metadata"`
+	// TODO: implement an explicitly defined struct for HATEOSLinks
 	HATEOSLinks json.RawMessage `json:"hateosLinks"`
 }
 
+// MessageContent is the HTML or plain text body of a message, along with the links and images found in it.
+type MessageContent struct {
+	Links []*Link `json:"links"`
+	// Images is only set for HTML content.
+	Images []*Image `json:"images"`
+	Body   string   `json:"body"`
+}
+
+// Link is a hyperlink found in the body of a message.
+type Link struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Image is an image found in the HTML body of a message.
+type Image struct {
+	Src string `json:"src"`
+	Alt string `json:"alt"`
+}
+
+// Attachment is a file attached to a message, use DownloadAttachment to fetch its content.
+type Attachment struct {
+	Id          string `json:"id"`
+	ContentType string `json:"contentType"`
+	FileName    string `json:"fileName"`
+	// ContentId identifies inline attachments, such as images, referenced from the HTML body by "cid:" URLs.
+	ContentId string `json:"contentId"`
+	Length    int64  `json:"length"`
+	URL       string `json:"url"`
+	// Content holds the attachment's bytes for messages built by ParseEML, it is not sent by the mailosaur API.
+	Content []byte `json:"-"`
+}
+
 // MessageSummary objects represent a summarized email or SMS received by Mailosaur.
 type MessageSummary struct {
 	baseMessage
 
 	Attachments int `json:"attachments"`
 }
+
+// AttachmentInput is a file to attach to a message sent through mailosaur. Content holds the raw file bytes, which are
+// base64 encoded when sent to the API.
+type AttachmentInput struct {
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content"`
+}
@@ -0,0 +1,96 @@
+package mailosaur
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// EmailClient is an email client that mailosaur can render previews of messages in.
+type EmailClient struct {
+	// Label identifies the client when generating previews.
+	Label            string `json:"label"`
+	Name             string `json:"name"`
+	PlatformGroup    string `json:"platformGroup"`
+	PlatformType     string `json:"platformType"`
+	PlatformVersion  string `json:"platformVersion"`
+	CanDisableImages bool   `json:"canDisableImages"`
+	Status           string `json:"status"`
+}
+
+// Preview is a screenshot of a message rendered in an email client. Previews are generated asynchronously, use
+// DownloadPreview to wait for and download the screenshot.
+type Preview struct {
+	Id            string `json:"id"`
+	EmailClient   string `json:"emailClient"`
+	DisableImages bool   `json:"disableImages"`
+}
+
+// ListEmailClients returns the email clients previews can be generated for.
+func (c *Client) ListEmailClients(ctx context.Context) ([]*EmailClient, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "previews/clients", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct{ Items []*EmailClient }
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GeneratePreviews requests screenshots of a message rendered in each of the email clients, identified by their
+// EmailClient labels.
+func (c *Client) GeneratePreviews(ctx context.Context, messageID string, clients []string) ([]*Preview, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("mailosaur: generating previews requires at least one email client")
+	}
+	type previewRequest struct {
+		EmailClient string `json:"emailClient"`
+	}
+	var req struct {
+		Previews []previewRequest `json:"previews"`
+	}
+	for _, label := range clients {
+		req.Previews = append(req.Previews, previewRequest{EmailClient: label})
+	}
+
+	httpResp, err := c.do(ctx, http.MethodPost, "messages/"+messageID+"/previews", nil, req)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct{ Items []*Preview }
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// DownloadPreview downloads the PNG screenshot of a preview, polling until mailosaur has finished generating it. It
+// gives up with the context's error when ctx is done. The caller must close the returned reader.
+func (c *Client) DownloadPreview(ctx context.Context, previewID string) (io.ReadCloser, error) {
+	for {
+		httpResp, err := c.do(ctx, http.MethodGet, "files/previews/"+previewID, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch httpResp.StatusCode {
+		case http.StatusOK:
+			return httpResp.Body, nil
+		case http.StatusAccepted:
+			httpResp.Body.Close()
+		default:
+			httpResp.Body.Close()
+			return nil, fmt.Errorf("mailosaur: downloading preview %s: unexpected status %s", previewID, httpResp.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
+	}
+}
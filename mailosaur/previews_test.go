@@ -0,0 +1,153 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEmailClients(t *testing.T) {
+	t.Run("calls email clients endpoint and returns clients", func(t *testing.T) {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, &TestResponse{
+			Body:       LoadTestData(t, "list_email_clients_success.json"),
+			StatusCode: http.StatusOK,
+		})
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		clients, err := c.ListEmailClients(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "/previews/clients", recvReq.URL.Path)
+		require.Equal(t, http.MethodGet, recvReq.Method)
+		require.Len(t, clients, 2)
+		require.Equal(t, "outlook-2019-windows", clients[0].Label)
+		require.True(t, clients[0].CanDisableImages)
+	})
+}
+
+func TestGeneratePreviews(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("requests previews for each client", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "generate_previews_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		msgID := RandomMessageID()
+		_, err := ts.client.GeneratePreviews(context.Background(), msgID, []string{"outlook-2019-windows", "gmail-chrome-web"})
+		require.NoError(t, err)
+		require.Equal(t, "/messages/"+msgID+"/previews", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodPost, ts.recvReq.Method)
+		require.JSONEq(t, `{"previews": [{"emailClient": "outlook-2019-windows"}, {"emailClient": "gmail-chrome-web"}]}`,
+			string(ts.recvReq.Body))
+	})
+
+	t.Run("returns previews", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "generate_previews_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		previews, err := ts.client.GeneratePreviews(context.Background(), RandomMessageID(), []string{"outlook-2019-windows"})
+		require.NoError(t, err)
+		require.Len(t, previews, 2)
+		require.Equal(t, "gmail-chrome-web", previews[1].EmailClient)
+	})
+
+	t.Run("requires email clients", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusOK})
+
+		_, err := ts.client.GeneratePreviews(context.Background(), RandomMessageID(), nil)
+		require.Error(t, err)
+		require.Empty(t, ts.recvReq.Method)
+	})
+}
+
+// NewPreviewHTTPServer starts an http server that reports a preview as still generating for the first pending
+// requests, then serves its image. Returns the server and a pointer to the number of requests received.
+func NewPreviewHTTPServer(t *testing.T, pending int64, image []byte) (*httptest.Server, *int64) {
+	var requests int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= pending {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, err := w.Write(image)
+		require.NoError(t, err)
+	}))
+	return s, &requests
+}
+
+func TestDownloadPreview(t *testing.T) {
+	t.Run("polls until preview is ready", func(t *testing.T) {
+		t.Parallel()
+		s, requests := NewPreviewHTTPServer(t, 2, []byte("png"))
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetPollInterval(5*time.Millisecond))
+
+		r, err := c.DownloadPreview(context.Background(), RandomMessageID())
+		require.NoError(t, err)
+		defer r.Close()
+		image, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "png", string(image))
+		require.EqualValues(t, 3, atomic.LoadInt64(requests))
+	})
+
+	t.Run("calls preview download endpoint", func(t *testing.T) {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, &TestResponse{Body: []byte("png"), StatusCode: http.StatusOK})
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		previewID := RandomMessageID()
+		r, err := c.DownloadPreview(context.Background(), previewID)
+		require.NoError(t, err)
+		r.Close()
+		require.Equal(t, "/files/previews/"+previewID, recvReq.URL.Path)
+	})
+
+	t.Run("gives up when context is done", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewPreviewHTTPServer(t, 1000, nil)
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetPollInterval(5*time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+		_, err := c.DownloadPreview(ctx, RandomMessageID())
+		require.Error(t, err)
+	})
+
+	t.Run("fails with api error on error status", func(t *testing.T) {
+		t.Parallel()
+		s, _ := NewTestHTTPServer(t, &TestResponse{StatusCode: http.StatusNotFound})
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		_, err := c.DownloadPreview(context.Background(), RandomMessageID())
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+}
@@ -0,0 +1,67 @@
+package mailosaur
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SetRateLimit limits the client to requestsPerSecond requests to the mailosaur API, allowing bursts of up to burst
+// requests. A request over the limit waits for its turn, failing with the context's error if ctx ends first. The limit
+// is shared by every request the client makes, including the parallel requests of batch operations like GetMessages.
+// A requestsPerSecond of zero or less disables the limit.
+func SetRateLimit(requestsPerSecond float64, burst int) clientOption {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = &rateLimiter{
+			interval: time.Duration(float64(time.Second) / requestsPerSecond),
+			burst:    burst,
+			now:      time.Now,
+		}
+	}
+}
+
+// rateLimiter spaces requests interval apart while allowing up to burst requests at once. It tracks the theoretical
+// time the next request would be sent at if requests were evenly spaced, see the generic cell rate algorithm.
+type rateLimiter struct {
+	interval time.Duration
+	burst    int
+	now      func() time.Time
+
+	mu  sync.Mutex
+	tat time.Time
+}
+
+// wait blocks until a request may be sent, or until ctx ends in which case the reserved slot is given back.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := l.now()
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	l.tat = tat.Add(l.interval)
+	delay := l.tat.Sub(now) - time.Duration(l.burst)*l.interval
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tat = l.tat.Add(-l.interval)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,53 @@
+package mailosaur_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	setup := func(t *testing.T) *mailosaur.Client {
+		t.Parallel()
+		s, _ := NewTestHTTPServer(t, &TestResponse{Body: []byte("{}"), StatusCode: http.StatusOK})
+		return mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL),
+			mailosaur.SetRateLimit(10, 2))
+	}
+
+	t.Run("allows bursts", func(t *testing.T) {
+		c := setup(t)
+		start := time.Now()
+		for i := 0; i < 2; i++ {
+			_, err := c.GetMessage(RandomMessageID())
+			require.NoError(t, err)
+		}
+		require.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	})
+
+	t.Run("delays requests over the limit", func(t *testing.T) {
+		c := setup(t)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := c.GetMessage(RandomMessageID())
+			require.NoError(t, err)
+		}
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	})
+
+	t.Run("fails when context ends while waiting", func(t *testing.T) {
+		c := setup(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		for i := 0; i < 2; i++ {
+			resp, err := c.CallContext(ctx, http.MethodGet, "messages", nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+		}
+		_, err := c.CallContext(ctx, http.MethodGet, "messages", nil, nil)
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+}
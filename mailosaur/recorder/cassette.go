@@ -0,0 +1,83 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Cassette holds the recorded interactions between a client and the mailosaur API.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a single recorded request and the response received for it.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request" yaml:"request"`
+	Response RecordedResponse `json:"response" yaml:"response"`
+}
+
+// RecordedRequest is the stored form of an outgoing request.
+type RecordedRequest struct {
+	Method  string      `json:"method" yaml:"method"`
+	Path    string      `json:"path" yaml:"path"`
+	Query   string      `json:"query,omitempty" yaml:"query,omitempty"`
+	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// RecordedResponse is the stored form of a response received from the API.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode" yaml:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// isYAML reports whether the cassette at path should be encoded as YAML rather than JSON.
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// loadCassette reads a cassette from disk, the encoding is chosen by the file extension.
+func loadCassette(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if isYAML(path) {
+		err = yaml.Unmarshal(b, &c)
+	} else {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// save writes the cassette to disk, creating any missing parent directories.
+func (c *Cassette) save(path string) error {
+	var (
+		b   []byte
+		err error
+	)
+	if isYAML(path) {
+		b, err = yaml.Marshal(c)
+	} else {
+		b, err = json.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,334 @@
+// recorder provides an http.RoundTripper that records mailosaur API traffic to cassettes on disk and replays it,
+// allowing tests written against the live API to run deterministically without network access.
+//
+//	rec, err := recorder.New("testdata/inbox.json", recorder.ModeReplay)
+//	...
+//	c := mailosaur.NewClient(apiKey, serverID, mailosaur.SetHTTPTransport(rec))
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Mode controls how a Recorder handles requests.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the network.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the network and records every exchange into a new cassette.
+	ModeRecord
+	// ModePassthrough sends requests to the network without reading or writing the cassette.
+	ModePassthrough
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModePassthrough:
+		return "passthrough"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Redacted replaces scrubbed secrets in recorded cassettes.
+const Redacted = "[REDACTED]"
+
+// MissError is returned by a replaying Recorder when no unused recorded interaction matches a request.
+type MissError struct {
+	Cassette string
+	Method   string
+	URL      string
+	Body     string
+}
+
+func (e *MissError) Error() string {
+	msg := fmt.Sprintf("recorder: no unused interaction in cassette %q matches %s %s", e.Cassette, e.Method, e.URL)
+	if e.Body != "" {
+		msg += " with body " + e.Body
+	}
+	return msg
+}
+
+// Recorder is an http.RoundTripper that records and replays HTTP interactions.
+type Recorder struct {
+	path         string
+	mode         Mode
+	transport    http.RoundTripper
+	scrubHeaders []string
+	scrubFields  []string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// recorderOption is an option function that configures a Recorder
+type recorderOption func(*Recorder)
+
+// SetTransport sets the transport used to reach the network in record and passthrough modes, defaults to
+// http.DefaultTransport.
+func SetTransport(transport http.RoundTripper) recorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// SetScrubHeaders adds request and response headers whose values are redacted before being written to the cassette.
+// Authorization and Proxy-Authorization are always redacted.
+func SetScrubHeaders(headers ...string) recorderOption {
+	return func(r *Recorder) {
+		r.scrubHeaders = append(r.scrubHeaders, headers...)
+	}
+}
+
+// SetScrubFields adds JSON body fields whose values are redacted before being written to the cassette. Fields named
+// password, such as mailosaur server passwords, are always redacted.
+func SetScrubFields(fields ...string) recorderOption {
+	return func(r *Recorder) {
+		r.scrubFields = append(r.scrubFields, fields...)
+	}
+}
+
+// New creates a Recorder backed by the cassette at path. Cassettes with a .yaml or .yml extension are stored as YAML,
+// all others as JSON. In ModeReplay the cassette must already exist, in ModeRecord any existing cassette is replaced
+// when Save is called.
+func New(path string, mode Mode, options ...recorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:         path,
+		mode:         mode,
+		transport:    http.DefaultTransport,
+		scrubHeaders: []string{"Authorization", "Proxy-Authorization"},
+		scrubFields:  []string{"password"},
+		cassette:     &Cassette{},
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	switch mode {
+	case ModeReplay:
+		c, err := loadCassette(path)
+		if err != nil {
+			return nil, fmt.Errorf("recorder: loading cassette for replay: %w", err)
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	case ModeRecord, ModePassthrough:
+	default:
+		return nil, fmt.Errorf("recorder: unknown mode %v", mode)
+	}
+	return r, nil
+}
+
+// Mode returns the mode the Recorder was created with.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Save writes the recorded interactions to the cassette file. It is a no-op unless the Recorder is in ModeRecord.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.save(r.path)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch r.mode {
+	case ModePassthrough:
+		return r.transport.RoundTrip(req)
+	case ModeRecord:
+		return r.record(req)
+	default:
+		return r.replay(req)
+	}
+}
+
+// record sends the request to the network and stores the scrubbed exchange in the cassette.
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the caller's request, so the drained body is swapped on a copy
+	req = req.Clone(req.Context())
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   req.URL.Query().Encode(),
+			Headers: r.scrubHeaderValues(req.Header),
+			Body:    r.scrubBody(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    r.scrubHeaderValues(resp.Header),
+			Body:       r.scrubBody(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay finds the first unused interaction matching the request and builds a response from it.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	body := r.scrubBody(reqBody)
+	query := req.URL.Query().Encode()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !matches(&interaction.Request, req.Method, req.URL.Path, query, body) {
+			continue
+		}
+		r.used[i] = true
+
+		recorded := interaction.Response
+		header := http.Header{}
+		for key, values := range recorded.Headers {
+			header[key] = append([]string(nil), values...)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, &MissError{
+		Cassette: r.path,
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Body:     body,
+	}
+}
+
+// matches reports whether a recorded request matches the given method, path, query and body. JSON bodies are compared
+// semantically so that field ordering does not cause a miss.
+func matches(recorded *RecordedRequest, method, path, query, body string) bool {
+	if recorded.Method != method || recorded.Path != path || recorded.Query != query {
+		return false
+	}
+	if recorded.Body == body {
+		return true
+	}
+	var a, b interface{}
+	if json.Unmarshal([]byte(recorded.Body), &a) != nil || json.Unmarshal([]byte(body), &b) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// drainBody reads a request or response body fully, replacing it with a fresh reader over the same bytes.
+func drainBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil {
+		return nil, nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	if err := (*body).Close(); err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// scrubHeaderValues returns a copy of the headers with secret values redacted.
+func (r *Recorder) scrubHeaderValues(header http.Header) http.Header {
+	scrubbed := http.Header{}
+	for key, values := range header {
+		scrubbed[key] = append([]string(nil), values...)
+	}
+	for _, name := range r.scrubHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, Redacted)
+		}
+	}
+	return scrubbed
+}
+
+// scrubBody redacts secret fields from JSON bodies, bodies that are not JSON are returned unchanged.
+func (r *Recorder) scrubBody(body []byte) string {
+	var v interface{}
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return string(body)
+	}
+	if !r.scrubValue(v) {
+		return string(body)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(b)
+}
+
+// scrubValue walks a decoded JSON value redacting secret fields in place, reporting whether anything was redacted.
+func (r *Recorder) scrubValue(v interface{}) bool {
+	scrubbed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if r.isSecretField(key) {
+				v[key] = Redacted
+				scrubbed = true
+				continue
+			}
+			scrubbed = r.scrubValue(value) || scrubbed
+		}
+	case []interface{}:
+		for _, value := range v {
+			scrubbed = r.scrubValue(value) || scrubbed
+		}
+	}
+	return scrubbed
+}
+
+func (r *Recorder) isSecretField(name string) bool {
+	for _, field := range r.scrubFields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
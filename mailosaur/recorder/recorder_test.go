@@ -0,0 +1,191 @@
+package recorder_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/jslang/mailosaur-go/mailosaur/recorder"
+	"github.com/stretchr/testify/require"
+)
+
+const serverBody = `{"id": "abc123", "name": "My Server", "password": "hunter2"}`
+
+// tempDir creates a temporary directory for cassettes, callers are responsible for removing it.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "recorder")
+	require.NoError(t, err)
+	return dir
+}
+
+// newAPIServer starts a fake mailosaur API that answers every request with serverBody, counting the requests it
+// receives.
+func newAPIServer(t *testing.T) (*httptest.Server, *int) {
+	var calls int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(serverBody))
+		require.NoError(t, err)
+	}))
+	return s, &calls
+}
+
+// record performs a search through a recording client and saves the cassette.
+func record(t *testing.T, path string, serviceURL string) {
+	rec, err := recorder.New(path, recorder.ModeRecord)
+	require.NoError(t, err)
+	c := mailosaur.NewClient("secret-key", "server", mailosaur.SetServiceURL(serviceURL), mailosaur.SetHTTPTransport(rec))
+	_, err = c.SearchMessages(&mailosaur.SearchMessagesLookup{SentTo: "a@b.c", Subject: "hello"})
+	require.NoError(t, err)
+	require.NoError(t, rec.Save())
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("replays recorded interactions without network", func(t *testing.T) {
+		s, calls := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.json")
+		record(t, path, s.URL)
+		require.Equal(t, 1, *calls)
+
+		rec, err := recorder.New(path, recorder.ModeReplay)
+		require.NoError(t, err)
+		c := mailosaur.NewClient("other-key", "server", mailosaur.SetServiceURL(s.URL), mailosaur.SetHTTPTransport(rec))
+		resp, err := c.Call(http.MethodPost, "messages/search", map[string]interface{}{"server": "server"},
+			map[string]string{"subject": "hello", "sentTo": "a@b.c"})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, 1, *calls)
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id": "abc123", "name": "My Server", "password": "[REDACTED]"}`, string(body))
+	})
+
+	t.Run("scrubs secrets from cassette", func(t *testing.T) {
+		s, _ := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.json")
+		record(t, path, s.URL)
+
+		b, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(b), "hunter2")
+		require.NotContains(t, string(b), "Basic ")
+		require.Contains(t, string(b), recorder.Redacted)
+	})
+
+	t.Run("stores yaml cassettes", func(t *testing.T) {
+		s, _ := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.yaml")
+		record(t, path, s.URL)
+
+		b, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(b), "interactions:"))
+
+		rec, err := recorder.New(path, recorder.ModeReplay)
+		require.NoError(t, err)
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(s.URL), mailosaur.SetHTTPTransport(rec))
+		_, err = c.SearchMessages(&mailosaur.SearchMessagesLookup{SentTo: "a@b.c", Subject: "hello"})
+		require.NoError(t, err)
+	})
+
+	t.Run("returns miss error for unmatched request", func(t *testing.T) {
+		s, _ := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.json")
+		record(t, path, s.URL)
+
+		rec, err := recorder.New(path, recorder.ModeReplay)
+		require.NoError(t, err)
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(s.URL), mailosaur.SetHTTPTransport(rec))
+		_, err = c.SearchMessages(&mailosaur.SearchMessagesLookup{Subject: "different"})
+		require.Error(t, err)
+
+		var miss *recorder.MissError
+		require.True(t, errors.As(err, &miss))
+		require.Equal(t, http.MethodPost, miss.Method)
+		require.Contains(t, miss.Error(), "different")
+	})
+
+	t.Run("does not replay an interaction twice", func(t *testing.T) {
+		s, _ := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.json")
+		record(t, path, s.URL)
+
+		rec, err := recorder.New(path, recorder.ModeReplay)
+		require.NoError(t, err)
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(s.URL), mailosaur.SetHTTPTransport(rec))
+		lookup := &mailosaur.SearchMessagesLookup{SentTo: "a@b.c", Subject: "hello"}
+		_, err = c.SearchMessages(lookup)
+		require.NoError(t, err)
+		_, err = c.SearchMessages(lookup)
+		require.Error(t, err)
+	})
+
+	t.Run("replay requires existing cassette", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		_, err := recorder.New(filepath.Join(dir, "missing.json"), recorder.ModeReplay)
+		require.Error(t, err)
+	})
+
+	t.Run("passthrough does not record", func(t *testing.T) {
+		s, calls := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.json")
+		rec, err := recorder.New(path, recorder.ModePassthrough)
+		require.NoError(t, err)
+		c := mailosaur.NewClient("key", "server", mailosaur.SetServiceURL(s.URL), mailosaur.SetHTTPTransport(rec))
+		_, err = c.ListMessages()
+		require.NoError(t, err)
+		require.Equal(t, 1, *calls)
+		require.NoError(t, rec.Save())
+		_, err = os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("does not modify the caller's request", func(t *testing.T) {
+		s, _ := newAPIServer(t)
+		defer s.Close()
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cassette.json")
+
+		for _, mode := range []recorder.Mode{recorder.ModeRecord, recorder.ModeReplay} {
+			rec, err := recorder.New(path, mode)
+			require.NoError(t, err)
+			body := ioutil.NopCloser(strings.NewReader(`{"subject": "hello"}`))
+			req, err := http.NewRequest(http.MethodPost, s.URL+"/messages/search", body)
+			require.NoError(t, err)
+
+			resp, err := rec.RoundTrip(req)
+			require.NoError(t, err, mode.String())
+			resp.Body.Close()
+			require.Equal(t, body, req.Body, mode.String())
+			require.NoError(t, rec.Save())
+		}
+	})
+}
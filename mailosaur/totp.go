@@ -0,0 +1,160 @@
+package mailosaur
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTPAlgorithm is the HMAC hash function used to generate time-based one-time passwords.
+type TOTPAlgorithm string
+
+// Supported TOTP algorithms, as named in otpauth:// URIs.
+const (
+	TOTPSHA1   TOTPAlgorithm = "SHA1"
+	TOTPSHA256 TOTPAlgorithm = "SHA256"
+	TOTPSHA512 TOTPAlgorithm = "SHA512"
+)
+
+// totpConfig holds the parameters of a TOTP generator, the defaults match those used by authenticator apps.
+type totpConfig struct {
+	period    time.Duration
+	digits    int
+	algorithm TOTPAlgorithm
+}
+
+// totpOption configures GenerateTOTP
+type totpOption func(*totpConfig)
+
+// SetTOTPPeriod sets how long each code is valid for, defaults to 30 seconds.
+func SetTOTPPeriod(period time.Duration) totpOption {
+	return func(c *totpConfig) {
+		c.period = period
+	}
+}
+
+// SetTOTPDigits sets the number of digits in each code, defaults to 6.
+func SetTOTPDigits(digits int) totpOption {
+	return func(c *totpConfig) {
+		c.digits = digits
+	}
+}
+
+// SetTOTPAlgorithm sets the HMAC hash function, defaults to TOTPSHA1.
+func SetTOTPAlgorithm(algorithm TOTPAlgorithm) totpOption {
+	return func(c *totpConfig) {
+		c.algorithm = algorithm
+	}
+}
+
+// GenerateTOTP computes the RFC 6238 time-based one-time password for secret at time t, without calling the mailosaur
+// API. This allows codes to be computed locally and compared with DeviceOTP when a device's secret is known. The secret
+// is either a base32 shared secret or an otpauth:// URI, whose period, digits and algorithm parameters are used unless
+// overridden by options.
+func GenerateTOTP(secret string, t time.Time, options ...totpOption) (string, error) {
+	cfg := totpConfig{period: 30 * time.Second, digits: 6, algorithm: TOTPSHA1}
+	if strings.HasPrefix(strings.ToLower(secret), "otpauth://") {
+		var err error
+		if secret, err = parseOTPAuthURI(secret, &cfg); err != nil {
+			return "", err
+		}
+	}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	key, err := decodeBase32Secret(secret)
+	if err != nil {
+		return "", err
+	}
+	if cfg.period < time.Second {
+		return "", fmt.Errorf("mailosaur: TOTP period %v is shorter than one second", cfg.period)
+	}
+	if cfg.digits < 1 || cfg.digits > 10 {
+		return "", fmt.Errorf("mailosaur: TOTP digits %d out of range 1-10", cfg.digits)
+	}
+	var newHash func() hash.Hash
+	switch TOTPAlgorithm(strings.ToUpper(string(cfg.algorithm))) {
+	case TOTPSHA1:
+		newHash = sha1.New
+	case TOTPSHA256:
+		newHash = sha256.New
+	case TOTPSHA512:
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("mailosaur: unsupported TOTP algorithm %q", cfg.algorithm)
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(cfg.period/time.Second)))
+	mac := hmac.New(newHash, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < cfg.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", cfg.digits, code%mod), nil
+}
+
+// parseOTPAuthURI reads the secret and generator parameters from an otpauth:// URI into cfg, returning the secret.
+func parseOTPAuthURI(uri string, cfg *totpConfig) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("mailosaur: parsing otpauth URI: %w", err)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return "", fmt.Errorf("mailosaur: otpauth URI type %q is not totp", u.Host)
+	}
+	query := u.Query()
+	secret := query.Get("secret")
+	if secret == "" {
+		return "", fmt.Errorf("mailosaur: otpauth URI has no secret")
+	}
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil {
+			return "", fmt.Errorf("mailosaur: otpauth URI period %q: %w", period, err)
+		}
+		cfg.period = time.Duration(seconds) * time.Second
+	}
+	if digits := query.Get("digits"); digits != "" {
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return "", fmt.Errorf("mailosaur: otpauth URI digits %q: %w", digits, err)
+		}
+		cfg.digits = n
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		cfg.algorithm = TOTPAlgorithm(algorithm)
+	}
+	return secret, nil
+}
+
+// decodeBase32Secret decodes a base32 shared secret, tolerating the lower case, spacing and missing padding commonly
+// used when secrets are displayed to users.
+func decodeBase32Secret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	secret = strings.TrimRight(secret, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("mailosaur: decoding base32 TOTP secret: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("mailosaur: TOTP secret is empty")
+	}
+	return key, nil
+}
@@ -0,0 +1,87 @@
+package mailosaur_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTOTP(t *testing.T) {
+	// test vectors from RFC 6238 appendix B
+	secrets := map[mailosaur.TOTPAlgorithm]string{
+		mailosaur.TOTPSHA1:   base32.StdEncoding.EncodeToString([]byte("12345678901234567890")),
+		mailosaur.TOTPSHA256: base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012")),
+		mailosaur.TOTPSHA512: base32.StdEncoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234")),
+	}
+	vectors := []struct {
+		unix      int64
+		algorithm mailosaur.TOTPAlgorithm
+		code      string
+	}{
+		{59, mailosaur.TOTPSHA1, "94287082"},
+		{59, mailosaur.TOTPSHA256, "46119246"},
+		{59, mailosaur.TOTPSHA512, "90693936"},
+		{1111111109, mailosaur.TOTPSHA1, "07081804"},
+		{1111111109, mailosaur.TOTPSHA256, "68084774"},
+		{1111111109, mailosaur.TOTPSHA512, "25091201"},
+		{1234567890, mailosaur.TOTPSHA1, "89005924"},
+		{2000000000, mailosaur.TOTPSHA256, "90698825"},
+		{20000000000, mailosaur.TOTPSHA512, "47863826"},
+	}
+
+	t.Run("matches rfc 6238 test vectors", func(t *testing.T) {
+		for _, v := range vectors {
+			code, err := mailosaur.GenerateTOTP(secrets[v.algorithm], time.Unix(v.unix, 0),
+				mailosaur.SetTOTPDigits(8), mailosaur.SetTOTPAlgorithm(v.algorithm))
+			require.NoError(t, err)
+			require.Equal(t, v.code, code, "%s at %d", v.algorithm, v.unix)
+		}
+	})
+
+	t.Run("defaults to six digits", func(t *testing.T) {
+		code, err := mailosaur.GenerateTOTP(secrets[mailosaur.TOTPSHA1], time.Unix(59, 0))
+		require.NoError(t, err)
+		require.Equal(t, "287082", code)
+	})
+
+	t.Run("accepts lower case unpadded secrets", func(t *testing.T) {
+		code, err := mailosaur.GenerateTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0))
+		require.NoError(t, err)
+		require.Equal(t, "287082", code)
+	})
+
+	t.Run("uses period", func(t *testing.T) {
+		a, err := mailosaur.GenerateTOTP(secrets[mailosaur.TOTPSHA1], time.Unix(0, 0), mailosaur.SetTOTPPeriod(time.Minute))
+		require.NoError(t, err)
+		b, err := mailosaur.GenerateTOTP(secrets[mailosaur.TOTPSHA1], time.Unix(59, 0), mailosaur.SetTOTPPeriod(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, a, b)
+	})
+
+	t.Run("reads otpauth uri parameters", func(t *testing.T) {
+		uri := "otpauth://totp/Example:alice@example.com?secret=" + secrets[mailosaur.TOTPSHA256] +
+			"&issuer=Example&algorithm=SHA256&digits=8&period=30"
+		code, err := mailosaur.GenerateTOTP(uri, time.Unix(59, 0))
+		require.NoError(t, err)
+		require.Equal(t, "46119246", code)
+	})
+
+	t.Run("options override otpauth uri parameters", func(t *testing.T) {
+		uri := "otpauth://totp/Example?secret=" + secrets[mailosaur.TOTPSHA1] + "&digits=8"
+		code, err := mailosaur.GenerateTOTP(uri, time.Unix(59, 0), mailosaur.SetTOTPDigits(6))
+		require.NoError(t, err)
+		require.Equal(t, "287082", code)
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		for _, secret := range []string{"", "not base32!", "otpauth://hotp/Example?secret=GEZDGNBV", "otpauth://totp/Example"} {
+			_, err := mailosaur.GenerateTOTP(secret, time.Now())
+			require.Error(t, err, secret)
+		}
+		_, err := mailosaur.GenerateTOTP(secrets[mailosaur.TOTPSHA1], time.Now(), mailosaur.SetTOTPAlgorithm("MD5"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,171 @@
+package mailosaur
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// TrackingRules describes the tracking parameters and destinations required of every link in a marketing message.
+type TrackingRules struct {
+	// RequiredParams must be present with a value on every link's destination, e.g. "utm_source".
+	RequiredParams []string
+	// AllowedValues restricts the values of query parameters when they are present, e.g.
+	// {"utm_medium": {"email"}}.
+	AllowedValues map[string][]string
+	// AllowedDomains lists the domains, and their subdomains, links may lead to. Any domain is allowed if it is empty.
+	AllowedDomains []string
+}
+
+// trackingOption configures ValidateTracking
+type trackingOption func(*trackingOptions)
+
+type trackingOptions struct {
+	unwrap bool
+	client *http.Client
+}
+
+// SetUnwrapRedirects makes ValidateTracking follow each link's redirects, using client or http.DefaultClient if client
+// is nil, and validate the final destination rather than the link itself, e.g. to look through click tracking URLs.
+func SetUnwrapRedirects(client *http.Client) trackingOption {
+	return func(o *trackingOptions) {
+		o.unwrap = true
+		o.client = client
+	}
+}
+
+// TrackedLink is the result of validating a single link.
+type TrackedLink struct {
+	URL string
+	// Destination is the URL the rules were checked against, where the link finally leads after any unwrapped
+	// redirects.
+	Destination string
+	Redirects   []string
+	// Violations lists every rule the link breaks, it is empty for a valid link.
+	Violations []string
+}
+
+// TrackingReport holds the result of validating every link in a message.
+type TrackingReport struct {
+	Links []*TrackedLink
+}
+
+// TrackingError lists the links of a TrackingReport that break the rules.
+type TrackingError struct {
+	Links []*TrackedLink
+}
+
+func (e *TrackingError) Error() string {
+	links := make([]string, 0, len(e.Links))
+	for _, link := range e.Links {
+		links = append(links, fmt.Sprintf("%s: %s", link.URL, strings.Join(link.Violations, ", ")))
+	}
+	return fmt.Sprintf("mailosaur: %d link(s) break tracking rules: %s", len(e.Links), strings.Join(links, "; "))
+}
+
+// Err returns a *TrackingError listing every link that breaks the rules, or nil if there are none.
+func (r *TrackingReport) Err() error {
+	var invalid []*TrackedLink
+	for _, link := range r.Links {
+		if len(link.Violations) > 0 {
+			invalid = append(invalid, link)
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	return &TrackingError{Links: invalid}
+}
+
+// ValidateTracking checks every distinct http(s) link in the HTML and text bodies of a message against the rules.
+// Links are reported in the order they appear in the message.
+func ValidateTracking(ctx context.Context, msg *Message, rules TrackingRules, options ...trackingOption) *TrackingReport {
+	var o trackingOptions
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	var links []string
+	for _, link := range messageLinks(msg) {
+		if u, err := url.Parse(link); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			links = append(links, link)
+		}
+	}
+	report := &TrackingReport{Links: make([]*TrackedLink, len(links))}
+	for i, link := range links {
+		report.Links[i] = &TrackedLink{URL: link, Destination: link}
+	}
+
+	if o.unwrap {
+		checkOptions := &linkCheckOptions{maxRedirects: defaultMaxRedirects}
+		client := noFollowClient(o.client)
+		errs := forEach(ctx, len(links), defaultConcurrency, func(ctx context.Context, i int) error {
+			result := &LinkResult{URL: links[i]}
+			checkOptions.visit(ctx, client, result)
+			tracked := report.Links[i]
+			tracked.Redirects = result.Redirects
+			if len(result.Redirects) > 0 {
+				tracked.Destination = result.Redirects[len(result.Redirects)-1]
+			}
+			if result.Err != nil {
+				tracked.Violations = append(tracked.Violations, fmt.Sprintf("could not follow redirects: %v", result.Err))
+			}
+			return nil
+		})
+		for i, err := range errs {
+			if err != nil {
+				// the link was not visited before ctx ended, so its destination is unknown
+				report.Links[i].Violations = append(report.Links[i].Violations, fmt.Sprintf("not unwrapped: %v", err))
+			}
+		}
+	}
+
+	for _, link := range report.Links {
+		link.Violations = append(link.Violations, rules.violations(link.Destination)...)
+	}
+	return report
+}
+
+// violations returns every rule destination breaks.
+func (r TrackingRules) violations(destination string) []string {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid destination: %v", err)}
+	}
+
+	var violations []string
+	if len(r.AllowedDomains) > 0 && !inDomains(u.Hostname(), r.AllowedDomains) {
+		violations = append(violations, fmt.Sprintf("destination %s is not an allowed domain", u.Hostname()))
+	}
+	query := u.Query()
+	for _, param := range r.RequiredParams {
+		if query.Get(param) == "" {
+			violations = append(violations, "missing "+param)
+		}
+	}
+	params := make([]string, 0, len(r.AllowedValues))
+	for param := range r.AllowedValues {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		allowed := r.AllowedValues[param]
+		if value := query.Get(param); value != "" && !containsString(allowed, value) {
+			violations = append(violations, fmt.Sprintf("%s is %q, want one of %s", param, value,
+				strings.Join(allowed, ", ")))
+		}
+	}
+	return violations
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
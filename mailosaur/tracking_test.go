@@ -0,0 +1,98 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTracking(t *testing.T) {
+	rules := mailosaur.TrackingRules{
+		RequiredParams: []string{"utm_source", "utm_medium", "utm_campaign"},
+		AllowedValues: map[string][]string{
+			"utm_source": {"newsletter"},
+			"utm_medium": {"email"},
+		},
+		AllowedDomains: []string{"example.com"},
+	}
+
+	t.Run("valid links", func(t *testing.T) {
+		t.Parallel()
+		msg := LinksMessage("https://shop.example.com/sale?utm_source=newsletter&utm_medium=email&utm_campaign=spring",
+			"mailto:help@example.com")
+
+		report := mailosaur.ValidateTracking(context.Background(), msg, rules)
+		require.NoError(t, report.Err())
+		require.Len(t, report.Links, 1)
+		require.Equal(t, report.Links[0].URL, report.Links[0].Destination)
+	})
+
+	t.Run("reports violations per link", func(t *testing.T) {
+		t.Parallel()
+		msg := LinksMessage("https://example.com/a?utm_source=newsletter&utm_medium=email&utm_campaign=spring",
+			"https://example.com/b?utm_source=facebook&utm_medium=email",
+			"https://example.org/c?utm_source=newsletter&utm_medium=social&utm_campaign=spring")
+
+		report := mailosaur.ValidateTracking(context.Background(), msg, rules)
+		require.Empty(t, report.Links[0].Violations)
+		require.Equal(t, []string{"missing utm_campaign", `utm_source is "facebook", want one of newsletter`},
+			report.Links[1].Violations)
+		require.Equal(t, []string{"destination example.org is not an allowed domain",
+			`utm_medium is "social", want one of email`}, report.Links[2].Violations)
+
+		var trackingErr *mailosaur.TrackingError
+		require.True(t, errors.As(report.Err(), &trackingErr))
+		require.Equal(t, []*mailosaur.TrackedLink{report.Links[1], report.Links[2]}, trackingErr.Links)
+	})
+
+	t.Run("unwraps tracking redirects", func(t *testing.T) {
+		t.Parallel()
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/click/1":
+				http.Redirect(w, r, "/landing?utm_source=newsletter&utm_medium=email&utm_campaign=spring", http.StatusFound)
+			case "/click/2":
+				http.Redirect(w, r, "/landing", http.StatusFound)
+			}
+		}))
+		defer s.Close()
+
+		msg := LinksMessage(s.URL+"/click/1", s.URL+"/click/2")
+		report := mailosaur.ValidateTracking(context.Background(), msg, mailosaur.TrackingRules{
+			RequiredParams: rules.RequiredParams,
+			AllowedDomains: []string{"127.0.0.1"},
+		}, mailosaur.SetUnwrapRedirects(s.Client()))
+
+		require.Equal(t, s.URL+"/landing?utm_source=newsletter&utm_medium=email&utm_campaign=spring",
+			report.Links[0].Destination)
+		require.Equal(t, []string{report.Links[0].Destination}, report.Links[0].Redirects)
+		require.Empty(t, report.Links[0].Violations)
+		require.Equal(t, []string{"missing utm_source", "missing utm_medium", "missing utm_campaign"},
+			report.Links[1].Violations)
+	})
+
+	t.Run("reports links not unwrapped before context ends", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		msg := LinksMessage("https://example.com/a?utm_source=newsletter&utm_medium=email&utm_campaign=spring")
+		report := mailosaur.ValidateTracking(ctx, msg, rules, mailosaur.SetUnwrapRedirects(nil))
+		require.Equal(t, []string{"not unwrapped: context canceled"}, report.Links[0].Violations)
+		require.Error(t, report.Err())
+	})
+
+	t.Run("without unwrapping checks the tracking link itself", func(t *testing.T) {
+		t.Parallel()
+		msg := LinksMessage("https://click.tracker.test/r/abc")
+
+		report := mailosaur.ValidateTracking(context.Background(), msg, rules)
+		require.Equal(t, "https://click.tracker.test/r/abc", report.Links[0].Destination)
+		require.Contains(t, report.Links[0].Violations, "destination click.tracker.test is not an allowed domain")
+	})
+}
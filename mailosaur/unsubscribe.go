@@ -0,0 +1,195 @@
+package mailosaur
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// OneClickUnsubscribe is the only value RFC 8058 allows in the List-Unsubscribe-Post header, it is also the body of
+// the one-click unsubscribe request.
+const OneClickUnsubscribe = "List-Unsubscribe=One-Click"
+
+// UnsubscribeReport describes how a message supports unsubscribing under RFC 2369 and one-click unsubscribing under
+// RFC 8058, as required of bulk senders by Gmail and Yahoo.
+type UnsubscribeReport struct {
+	// Mailto and HTTPS are the valid unsubscribe URIs listed in the List-Unsubscribe header.
+	Mailto []*url.URL
+	HTTPS  []*url.URL
+	// PostStatus is the status code returned by the one-click unsubscribe request, zero if it was not made.
+	PostStatus int
+	// Problems lists everything that is not compliant, it is empty for a compliant message.
+	Problems []string
+}
+
+// UnsubscribeError lists every problem found by CheckUnsubscribe.
+type UnsubscribeError struct {
+	Problems []string
+}
+
+func (e *UnsubscribeError) Error() string {
+	return fmt.Sprintf("mailosaur: %d unsubscribe problem(s): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// Err returns an *UnsubscribeError listing every problem found, or nil if the message is compliant.
+func (r *UnsubscribeReport) Err() error {
+	if len(r.Problems) == 0 {
+		return nil
+	}
+	return &UnsubscribeError{Problems: r.Problems}
+}
+
+func (r *UnsubscribeReport) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// unsubscribeOption configures CheckUnsubscribe
+type unsubscribeOption func(*unsubscribeOptions)
+
+type unsubscribeOptions struct {
+	post   bool
+	client *http.Client
+}
+
+// SetOneClickPost makes CheckUnsubscribe perform the one-click unsubscribe request against the first HTTPS URI, using
+// client or http.DefaultClient if client is nil. Redirects are reported as problems rather than followed.
+func SetOneClickPost(client *http.Client) unsubscribeOption {
+	return func(o *unsubscribeOptions) {
+		o.post = true
+		o.client = client
+	}
+}
+
+// CheckUnsubscribe checks the List-Unsubscribe and List-Unsubscribe-Post headers of a message. The message must list
+// valid mailto or HTTPS URIs, offer one-click unsubscribe through an HTTPS URI and cover both headers with a DKIM
+// signature.
+func CheckUnsubscribe(ctx context.Context, msg *Message, options ...unsubscribeOption) *UnsubscribeReport {
+	var o unsubscribeOptions
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	report := &UnsubscribeReport{}
+	headers := msg.Metadata.Headers
+	if !headers.Has("List-Unsubscribe") {
+		report.problem("missing List-Unsubscribe header")
+		return report
+	}
+	parseUnsubscribeURIs(report, headers.Get("List-Unsubscribe"))
+	if len(report.HTTPS) == 0 {
+		report.problem("List-Unsubscribe has no HTTPS URI for one-click unsubscribe")
+	}
+
+	switch post := headers.Get("List-Unsubscribe-Post"); {
+	case !headers.Has("List-Unsubscribe-Post"):
+		report.problem("missing List-Unsubscribe-Post header")
+	case strings.TrimSpace(post) != OneClickUnsubscribe:
+		report.problem("List-Unsubscribe-Post is %q, want %q", post, OneClickUnsubscribe)
+	}
+
+	if !dkimCovers(headers, "List-Unsubscribe", "List-Unsubscribe-Post") {
+		report.problem("no DKIM signature covers both List-Unsubscribe and List-Unsubscribe-Post")
+	}
+
+	if o.post && len(report.HTTPS) > 0 {
+		postOneClick(ctx, report, o.client)
+	}
+	return report
+}
+
+// parseUnsubscribeURIs parses the comma separated, angle bracketed URIs of a List-Unsubscribe header.
+func parseUnsubscribeURIs(report *UnsubscribeReport, value string) {
+	for _, element := range strings.Split(value, ",") {
+		element = stripWhitespace(element)
+		if element == "" {
+			continue
+		}
+		if !strings.HasPrefix(element, "<") || !strings.HasSuffix(element, ">") {
+			report.problem("List-Unsubscribe URI %s is not enclosed in angle brackets", element)
+			continue
+		}
+		raw := element[1 : len(element)-1]
+		uri, err := url.Parse(raw)
+		if err != nil {
+			report.problem("List-Unsubscribe URI %s is invalid: %v", raw, err)
+			continue
+		}
+
+		switch strings.ToLower(uri.Scheme) {
+		case "mailto":
+			if _, err := mail.ParseAddress(uri.Opaque); err != nil {
+				report.problem("List-Unsubscribe URI %s has an invalid address", raw)
+				continue
+			}
+			report.Mailto = append(report.Mailto, uri)
+		case "https":
+			if uri.Host == "" {
+				report.problem("List-Unsubscribe URI %s has no host", raw)
+				continue
+			}
+			report.HTTPS = append(report.HTTPS, uri)
+		case "http":
+			report.problem("List-Unsubscribe URI %s must use HTTPS", raw)
+		default:
+			report.problem("List-Unsubscribe URI %s has unsupported scheme %q", raw, uri.Scheme)
+		}
+	}
+}
+
+// dkimCovers reports whether a single DKIM-Signature header signs every one of the named header fields.
+func dkimCovers(headers Headers, names ...string) bool {
+	for _, signature := range headers.Values("DKIM-Signature") {
+		tags, err := parseDKIMTags(signature)
+		if err != nil {
+			continue
+		}
+		signed := map[string]bool{}
+		for _, name := range strings.Split(tags["h"], ":") {
+			signed[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+		covered := true
+		for _, name := range names {
+			covered = covered && signed[strings.ToLower(name)]
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// postOneClick performs the RFC 8058 one-click unsubscribe request against the first HTTPS URI.
+func postOneClick(ctx context.Context, report *UnsubscribeReport, client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	uri := report.HTTPS[0].String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(OneClickUnsubscribe))
+	if err != nil {
+		report.problem("one-click unsubscribe request to %s failed: %v", uri, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := noRedirects.Do(req)
+	if err != nil {
+		report.problem("one-click unsubscribe request to %s failed: %v", uri, err)
+		return
+	}
+	resp.Body.Close()
+
+	report.PostStatus = resp.StatusCode
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		report.problem("one-click unsubscribe request to %s redirected to %s", uri, resp.Header.Get("Location"))
+	case resp.StatusCode >= 400:
+		report.problem("one-click unsubscribe request to %s returned %s", uri, resp.Status)
+	}
+}
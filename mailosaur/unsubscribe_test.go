@@ -0,0 +1,119 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+// UnsubscribeMessage returns a message with the List-Unsubscribe headers, signed by DKIM.
+func UnsubscribeMessage(listUnsubscribe string, listUnsubscribePost string) *mailosaur.Message {
+	msg := &mailosaur.Message{}
+	msg.Metadata.Headers = mailosaur.Headers{
+		{Field: "DKIM-Signature", Value: "v=1; a=rsa-sha256; d=example.com; s=mail;\r\n" +
+			" h=from:subject:list-unsubscribe:list-unsubscribe-post; bh=abc=; b=def="},
+		{Field: "List-Unsubscribe", Value: listUnsubscribe},
+		{Field: "List-Unsubscribe-Post", Value: listUnsubscribePost},
+	}
+	return msg
+}
+
+func TestCheckUnsubscribe(t *testing.T) {
+	t.Run("compliant message", func(t *testing.T) {
+		t.Parallel()
+		msg := UnsubscribeMessage("<mailto:unsubscribe@example.com?subject=stop>,\r\n <https://example.com/u/123>",
+			mailosaur.OneClickUnsubscribe)
+
+		report := mailosaur.CheckUnsubscribe(context.Background(), msg)
+		require.NoError(t, report.Err())
+		require.Len(t, report.Mailto, 1)
+		require.Len(t, report.HTTPS, 1)
+		require.Equal(t, "example.com", report.HTTPS[0].Host)
+		require.Zero(t, report.PostStatus)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		t.Parallel()
+		report := mailosaur.CheckUnsubscribe(context.Background(), &mailosaur.Message{})
+
+		var unsubscribeErr *mailosaur.UnsubscribeError
+		require.True(t, errors.As(report.Err(), &unsubscribeErr))
+		require.Equal(t, []string{"missing List-Unsubscribe header"}, unsubscribeErr.Problems)
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		t.Parallel()
+		msg := UnsubscribeMessage("<mailto:not-an-address>, http://example.com/u/123, <http://example.com/u/123>, "+
+			"<ftp://example.com/u>", "List-Unsubscribe=Yes")
+		msg.Metadata.Headers[0].Value = "v=1; h=from:subject:list-unsubscribe"
+
+		report := mailosaur.CheckUnsubscribe(context.Background(), msg)
+		require.Equal(t, []string{
+			"List-Unsubscribe URI mailto:not-an-address has an invalid address",
+			"List-Unsubscribe URI http://example.com/u/123 is not enclosed in angle brackets",
+			"List-Unsubscribe URI http://example.com/u/123 must use HTTPS",
+			`List-Unsubscribe URI ftp://example.com/u has unsupported scheme "ftp"`,
+			"List-Unsubscribe has no HTTPS URI for one-click unsubscribe",
+			`List-Unsubscribe-Post is "List-Unsubscribe=Yes", want "List-Unsubscribe=One-Click"`,
+			"no DKIM signature covers both List-Unsubscribe and List-Unsubscribe-Post",
+		}, report.Problems)
+	})
+
+	t.Run("missing post header", func(t *testing.T) {
+		t.Parallel()
+		msg := UnsubscribeMessage("<https://example.com/u/123>", "")
+		msg.Metadata.Headers = msg.Metadata.Headers[:2]
+
+		report := mailosaur.CheckUnsubscribe(context.Background(), msg)
+		require.Equal(t, []string{"missing List-Unsubscribe-Post header"}, report.Problems)
+	})
+
+	t.Run("performs one-click post", func(t *testing.T) {
+		t.Parallel()
+		type received struct {
+			method, contentType, body string
+		}
+		requests := make(chan received, 1)
+		s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests <- received{r.Method, r.Header.Get("Content-Type"), string(body)}
+		}))
+		defer s.Close()
+
+		msg := UnsubscribeMessage("<"+s.URL+"/u/123>", mailosaur.OneClickUnsubscribe)
+		report := mailosaur.CheckUnsubscribe(context.Background(), msg, mailosaur.SetOneClickPost(s.Client()))
+		require.NoError(t, report.Err())
+		require.Equal(t, http.StatusOK, report.PostStatus)
+		require.Equal(t, received{http.MethodPost, "application/x-www-form-urlencoded", mailosaur.OneClickUnsubscribe},
+			<-requests)
+	})
+
+	t.Run("reports redirected and failed posts", func(t *testing.T) {
+		t.Parallel()
+		s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/redirect" {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer s.Close()
+
+		report := mailosaur.CheckUnsubscribe(context.Background(),
+			UnsubscribeMessage("<"+s.URL+"/redirect>", mailosaur.OneClickUnsubscribe), mailosaur.SetOneClickPost(s.Client()))
+		require.Equal(t, http.StatusFound, report.PostStatus)
+		require.Equal(t, []string{"one-click unsubscribe request to " + s.URL + "/redirect redirected to /login"},
+			report.Problems)
+
+		report = mailosaur.CheckUnsubscribe(context.Background(),
+			UnsubscribeMessage("<"+s.URL+"/fail>", mailosaur.OneClickUnsubscribe), mailosaur.SetOneClickPost(s.Client()))
+		require.Equal(t, []string{"one-click unsubscribe request to " + s.URL + "/fail returned 500 Internal Server Error"},
+			report.Problems)
+	})
+}
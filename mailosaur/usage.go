@@ -0,0 +1,100 @@
+package mailosaur
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// UsageLimit is the current usage of a single account resource and the limit for it.
+type UsageLimit struct {
+	Limit   int `json:"limit"`
+	Current int `json:"current"`
+}
+
+// Percent returns current usage as a percentage of the limit, zero if there is no limit.
+func (l UsageLimit) Percent() float64 {
+	if l.Limit <= 0 {
+		return 0
+	}
+	return float64(l.Current) / float64(l.Limit) * 100
+}
+
+// UsageLimits reports account usage against each of the account's limits.
+type UsageLimits struct {
+	Servers UsageLimit `json:"servers"`
+	Users   UsageLimit `json:"users"`
+	Email   UsageLimit `json:"email"`
+	SMS     UsageLimit `json:"sms"`
+}
+
+// UsageWarning is returned by UsageLimits.CheckThreshold, listing each limit whose usage exceeds the threshold.
+type UsageWarning struct {
+	Threshold float64
+	Exceeded  map[string]UsageLimit
+}
+
+func (w *UsageWarning) Error() string {
+	var parts []string
+	for _, name := range []string{"servers", "users", "email", "sms"} {
+		if limit, ok := w.Exceeded[name]; ok {
+			parts = append(parts, fmt.Sprintf("%s %d/%d (%.0f%%)", name, limit.Current, limit.Limit, limit.Percent()))
+		}
+	}
+	return fmt.Sprintf("mailosaur: usage over %.0f%% of limit: %s", w.Threshold, strings.Join(parts, ", "))
+}
+
+// CheckThreshold returns a *UsageWarning if usage of any limit is over percent of that limit, for example to warn in
+// CI before the monthly email quota runs out. Limits of zero are ignored.
+func (l *UsageLimits) CheckThreshold(percent float64) error {
+	exceeded := map[string]UsageLimit{}
+	for name, limit := range map[string]UsageLimit{
+		"servers": l.Servers,
+		"users":   l.Users,
+		"email":   l.Email,
+		"sms":     l.SMS,
+	} {
+		if limit.Limit > 0 && limit.Percent() > percent {
+			exceeded[name] = limit
+		}
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	return &UsageWarning{Threshold: percent, Exceeded: exceeded}
+}
+
+// UsageTransaction records the number of emails and SMS messages processed by the account at a point in time.
+type UsageTransaction struct {
+	Timestamp time.Time `json:"timestamp"`
+	Email     int       `json:"email"`
+	SMS       int       `json:"sms"`
+}
+
+// UsageLimits retrieves the account's current usage and limits.
+func (c *Client) UsageLimits(ctx context.Context) (*UsageLimits, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "usage/limits", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var limits UsageLimits
+	if err := decodeJSON(httpResp, &limits); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// UsageTransactions retrieves the account's recent usage history.
+func (c *Client) UsageTransactions(ctx context.Context) ([]*UsageTransaction, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "usage/transactions", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct{ Items []*UsageTransaction }
+	if err := decodeJSON(httpResp, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
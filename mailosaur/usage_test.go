@@ -0,0 +1,110 @@
+package mailosaur_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jslang/mailosaur-go/mailosaur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageLimits(t *testing.T) {
+	type testSetup struct {
+		recvReq *ReceivedRequest
+		client  *mailosaur.Client
+	}
+
+	setup := func(t *testing.T, resp *TestResponse) *testSetup {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, resp)
+		return &testSetup{
+			recvReq: recvReq,
+			client:  mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL)),
+		}
+	}
+
+	t.Run("calls usage limits endpoint", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "usage_limits_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		_, err := ts.client.UsageLimits(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "/usage/limits", ts.recvReq.URL.Path)
+		require.Equal(t, http.MethodGet, ts.recvReq.Method)
+	})
+
+	t.Run("returns limits", func(t *testing.T) {
+		ts := setup(t, &TestResponse{
+			Body:       LoadTestData(t, "usage_limits_success.json"),
+			StatusCode: http.StatusOK,
+		})
+
+		limits, err := ts.client.UsageLimits(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, mailosaur.UsageLimit{Limit: 10, Current: 2}, limits.Servers)
+		require.Equal(t, mailosaur.UsageLimit{Limit: 5, Current: 4}, limits.Users)
+		require.Equal(t, mailosaur.UsageLimit{Limit: 1000, Current: 920}, limits.Email)
+		require.Equal(t, mailosaur.UsageLimit{}, limits.SMS)
+	})
+
+	t.Run("returns no limits for invalid response", func(t *testing.T) {
+		ts := setup(t, &TestResponse{Body: []byte("not json"), StatusCode: http.StatusOK})
+
+		limits, err := ts.client.UsageLimits(context.Background())
+		require.Error(t, err)
+		require.Nil(t, limits)
+	})
+
+	t.Run("returns api error", func(t *testing.T) {
+		ts := setup(t, &TestResponse{StatusCode: http.StatusUnauthorized})
+
+		limits, err := ts.client.UsageLimits(context.Background())
+		var apiErr *mailosaur.APIError
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		require.Nil(t, limits)
+	})
+}
+
+func TestUsageTransactions(t *testing.T) {
+	t.Run("calls usage transactions endpoint and returns transactions", func(t *testing.T) {
+		t.Parallel()
+		s, recvReq := NewTestHTTPServer(t, &TestResponse{
+			Body:       LoadTestData(t, "usage_transactions_success.json"),
+			StatusCode: http.StatusOK,
+		})
+		c := mailosaur.NewClient(RandomAPIKey(), RandomServerID(), mailosaur.SetServiceURL(s.URL))
+
+		transactions, err := c.UsageTransactions(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "/usage/transactions", recvReq.URL.Path)
+		require.Len(t, transactions, 2)
+		require.Equal(t, 120, transactions[0].Email)
+		require.Equal(t, 3, transactions[0].SMS)
+		require.Equal(t, 2019, transactions[0].Timestamp.Year())
+	})
+}
+
+func TestUsageLimitsCheckThreshold(t *testing.T) {
+	limits := &mailosaur.UsageLimits{
+		Servers: mailosaur.UsageLimit{Limit: 10, Current: 2},
+		Users:   mailosaur.UsageLimit{Limit: 5, Current: 4},
+		Email:   mailosaur.UsageLimit{Limit: 1000, Current: 920},
+	}
+
+	t.Run("no warning under threshold", func(t *testing.T) {
+		require.NoError(t, limits.CheckThreshold(95))
+	})
+
+	t.Run("warns for every limit over threshold", func(t *testing.T) {
+		err := limits.CheckThreshold(75)
+		var warning *mailosaur.UsageWarning
+		require.True(t, errors.As(err, &warning))
+		require.Len(t, warning.Exceeded, 2)
+		require.Equal(t, "mailosaur: usage over 75% of limit: users 4/5 (80%), email 920/1000 (92%)", err.Error())
+	})
+}